@@ -1,8 +1,7 @@
 package main
 
 import (
-	"fmt"
-	"os"
+	"context"
 	"os/signal"
 	"syscall"
 
@@ -17,8 +16,10 @@ import (
 	"github.com/vitistack/vitistack-operator/internal/repositories"
 	"github.com/vitistack/vitistack-operator/internal/services/dynamichandler"
 	"github.com/vitistack/vitistack-operator/internal/services/initializeservice"
+	"github.com/vitistack/vitistack-operator/internal/services/leaderelectionservice"
 	"github.com/vitistack/vitistack-operator/internal/settings"
 	"github.com/vitistack/vitistack-operator/pkg/consts"
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
 	"go.uber.org/automaxprocs/maxprocs"
 )
 
@@ -39,38 +40,109 @@ func main() {
 	}()
 
 	_, _ = maxprocs.Set(maxprocs.Logger(vlog.Logr().Info))
-	cancelChan := make(chan os.Signal, 1)
 
-	stop := make(chan struct{})
-	// catch SIGTERM or SIGINT.
-	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	// ctx is cancelled on SIGTERM or SIGINT, every shutdown path is derived from it
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 	var err error
 
 	k8sclient.Init()
 	initializeservice.CheckPrerequisites()
-	cache.Cache, err = cache.VitistackCache{}.NewVitistackCache()
+	cacheMode, err := cache.ParseMode(viper.GetString(consts.CACHE_MODE))
+	if err != nil {
+		vlog.Fatal("Invalid cache mode", err)
+	}
+	cacheBackend, err := cache.ParseBackend(viper.GetString(consts.CACHE_BACKEND))
+	if err != nil {
+		vlog.Fatal("Invalid cache backend", err)
+	}
+	cacheOptions := []cache.Option{
+		cache.WithMode(cacheMode),
+		cache.WithConfirmation(dynamicclienthandler.ConfirmedAt),
+		cache.WithMaxStaleness(viper.GetDuration(consts.CACHE_MAX_STALENESS)),
+		cache.WithKeyPrefix(viper.GetString(consts.CACHE_KEY_PREFIX)),
+	}
+	if cacheBackend == cache.BackendRedis {
+		cacheOptions = append(cacheOptions, cache.WithRedis(cache.RedisOptions{
+			Address:  viper.GetString(consts.CACHE_REDIS_ADDRESS),
+			Password: viper.GetString(consts.CACHE_REDIS_PASSWORD),
+			DB:       viper.GetInt(consts.CACHE_REDIS_DB),
+		}))
+	}
+	cache.Cache, err = cache.VitistackCache{}.NewVitistackCache(cacheOptions...)
 	if err != nil {
 		panic(err)
 	}
-
+	// The repositories register the converters the cache stores their kinds with, so they are
+	// created before the snapshot is loaded
 	repositories.InitializeRepositories()
+
+	snapshotPath := viper.GetString(consts.CACHE_SNAPSHOT_PATH)
+	if snapshotPath != "" && cacheBackend != cache.BackendMemory {
+		vlog.Warn("Cache snapshots need the memory backend, ignoring the snapshot path",
+			"backend: ", cacheBackend)
+		snapshotPath = ""
+	}
+	if snapshotPath != "" {
+		loaded, err := cache.Cache.LoadSnapshot(context.Background(), snapshotPath)
+		if err != nil {
+			vlog.Warn("Ignoring cache snapshot that could not be loaded",
+				"path: ", snapshotPath,
+				"error: ", err.Error())
+		} else {
+			vlog.Info("Loaded cache snapshot",
+				"path: ", snapshotPath,
+				"objects: ", loaded)
+		}
+	}
+
+	queuePolicy, err := eventmanager.ParseQueuePolicy(viper.GetString(consts.EVENT_QUEUE_POLICY))
+	if err != nil {
+		vlog.Fatal("Invalid event queue policy", err)
+	}
+	eventmanager.EventBus = eventmanager.NewEventManager(
+		eventmanager.WithHistorySize(viper.GetInt(consts.EVENT_HISTORY_SIZE)),
+		eventmanager.WithDefaultQueue(viper.GetInt(consts.EVENT_QUEUE_SIZE), queuePolicy),
+		eventmanager.WithDefaultRetry(eventmanager.RetryPolicy{
+			MaxAttempts: viper.GetInt(consts.EVENT_RETRY_MAX_ATTEMPTS),
+			BaseDelay:   viper.GetDuration(consts.EVENT_RETRY_BASE_DELAY),
+			MaxDelay:    viper.GetDuration(consts.EVENT_RETRY_MAX_DELAY),
+		}),
+		eventmanager.WithDeadLetterSize(viper.GetInt(consts.EVENT_DEAD_LETTER_SIZE)),
+	)
+
 	resourceloglistener.RegisterListeners()
-	resourcewriterlistener.RegisterWriters()
+	resourcewriterlistener.RegisterWriters(eventmanager.EventBus)
 
+	// Every replica watches resources and serves the API, but only the leader writes the Vitistack status
+	leaderElectionDone := make(chan struct{})
 	go func() {
-		httpserver.Start()
-		sig := <-cancelChan
-		_, _ = fmt.Println()
-		_, _ = fmt.Println(sig)
-		stop <- struct{}{}
+		defer close(leaderElectionDone)
+		err := leaderelectionservice.Run(ctx, resourcewriterlistener.RunWriters)
+		if err != nil {
+			vlog.Fatal("could not start leader election", err)
+		}
 	}()
 
-	resourcehandler := dynamichandler.NewDynamicClientHandler()
-	err = dynamicclienthandler.Start(k8sclient.DiscoveryClient, k8sclient.DynamicClient, resourcehandler, stop, cancelChan)
+	snapshotsDone := make(chan struct{})
+	go func() {
+		defer close(snapshotsDone)
+		if snapshotPath != "" {
+			cache.Cache.RunSnapshots(ctx, snapshotPath, viper.GetDuration(consts.CACHE_SNAPSHOT_INTERVAL))
+		}
+	}()
+
+	go httpserver.Start()
+
+	resourcehandler := dynamichandler.NewDynamicClientHandler(eventmanager.EventBus)
+	err = dynamicclienthandler.Start(k8sclient.DiscoveryClient, k8sclient.DynamicClient, resourcehandler, ctx.Done())
 	if err != nil {
 		vlog.Fatal("could not start dynamic client", err)
 	}
 
-	sig := <-cancelChan
-	vlog.Info("Caught signal", "signal", sig)
+	<-ctx.Done()
+	vlog.Info("Caught signal, shutting down")
+	stop()
+	<-leaderElectionDone
+	<-snapshotsDone
 }
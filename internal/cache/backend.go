@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Backend is where the cache keeps its entries
+type Backend string
+
+const (
+	// BackendMemory keeps the entries in the operator's memory
+	BackendMemory Backend = "memory"
+	// BackendRedis keeps the entries in a Redis-protocol server, the index stays in memory
+	BackendRedis Backend = "redis"
+)
+
+// redisConnectTimeout bounds the check that the Redis server is reachable when the cache is created
+const redisConnectTimeout = 5 * time.Second
+
+// ParseBackend parses a cache backend setting
+func ParseBackend(value string) (Backend, error) {
+	switch backend := Backend(value); backend {
+	case BackendMemory, BackendRedis:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown cache backend %q, expected %s or %s", value, BackendMemory, BackendRedis)
+	}
+}
+
+// RedisOptions is the connection to the Redis-protocol server of the redis backend
+type RedisOptions struct {
+	Address  string
+	Password string
+	DB       int
+}
+
+// WithRedis keeps the cache's entries in a Redis-protocol server instead of in memory.
+//
+// Redis is only a blob store: replicas do not share one view of the cache. The key, UID and label
+// index and the freshness of the entries stay in each replica's memory and are built from the
+// replica's own watchers. Every replica still watches every resource, and lookups by UID, kind or
+// label only find objects the replica itself has indexed.
+func WithRedis(options RedisOptions) Option {
+	return func(c *VitistackCache) {
+		c.redisOptions = &options
+	}
+}
+
+// WithKeyPrefix sets the prefix of every key the cache writes to its backend, so several
+// operators can share one Redis server
+func WithKeyPrefix(prefix string) Option {
+	return func(c *VitistackCache) {
+		c.prefix = prefix
+	}
+}
+
+// newBackendLayer creates the cache layer of the configured backend
+func (dccache *VitistackCache) newBackendLayer() error {
+	if dccache.redisOptions == nil {
+		dccache.cacheLayer = newMemoryLayer()
+		return nil
+	}
+
+	// Decoded objects only live in memory, a shared backend needs the same JSON on every replica
+	if dccache.mode == ModeObject {
+		return errors.New("the object cache mode needs the memory backend")
+	}
+	if dccache.redisOptions.Address == "" {
+		return errors.New("the redis cache backend needs an address")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     dccache.redisOptions.Address,
+		Password: dccache.redisOptions.Password,
+		DB:       dccache.redisOptions.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), redisConnectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("failed to connect to redis at %s: %w", dccache.redisOptions.Address, err)
+	}
+	dccache.cacheLayer = newRedisLayer(client, dccache.prefix)
+	return nil
+}
+
+// layerKey returns the key of an entry in the backend
+func (dccache VitistackCache) layerKey(key string) string {
+	return dccache.prefix + key
+}
+
+// fallibleLayer is a cache layer whose operations can fail, such as one on a network server.
+// kvcachehelper.CacheInterface has no way to report those failures, so the cache uses these
+// methods instead when the layer has them.
+type fallibleLayer interface {
+	get(ctx context.Context, key string) (any, bool, error)
+	set(ctx context.Context, key string, value any) error
+	remove(ctx context.Context, key string) (bool, error)
+}
+
+// layerGet returns the value of an entry in the backend
+func (dccache VitistackCache) layerGet(ctx context.Context, key string) (any, bool, error) {
+	layer, ok := dccache.cacheLayer.(fallibleLayer)
+	if !ok {
+		value, found := dccache.cacheLayer.Get(ctx, dccache.layerKey(key))
+		return value, found, nil
+	}
+	value, found, err := layer.get(ctx, dccache.layerKey(key))
+	if err != nil {
+		recordBackendError("get")
+		return nil, false, fmt.Errorf("failed to read %s from the cache backend: %w", key, err)
+	}
+	return value, found, nil
+}
+
+// layerSet stores the value of an entry in the backend
+func (dccache VitistackCache) layerSet(ctx context.Context, key string, value any) error {
+	layer, ok := dccache.cacheLayer.(fallibleLayer)
+	if !ok {
+		dccache.cacheLayer.Set(ctx, dccache.layerKey(key), value)
+		return nil
+	}
+	if err := layer.set(ctx, dccache.layerKey(key), value); err != nil {
+		recordBackendError("set")
+		return fmt.Errorf("failed to write %s to the cache backend: %w", key, err)
+	}
+	return nil
+}
+
+// layerRemove removes an entry from the backend, returning whether it existed
+func (dccache VitistackCache) layerRemove(ctx context.Context, key string) (bool, error) {
+	layer, ok := dccache.cacheLayer.(fallibleLayer)
+	if !ok {
+		return dccache.cacheLayer.Remove(ctx, dccache.layerKey(key)), nil
+	}
+	removed, err := layer.remove(ctx, dccache.layerKey(key))
+	if err != nil {
+		recordBackendError("remove")
+		return false, fmt.Errorf("failed to remove %s from the cache backend: %w", key, err)
+	}
+	return removed, nil
+}
+
+// layerKeys returns the keys of the cache's entries in the backend, without the prefix
+func (dccache VitistackCache) layerKeys(ctx context.Context) ([]string, error) {
+	layerKeys, err := dccache.cacheLayer.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(layerKeys))
+	for _, layerKey := range layerKeys {
+		if key, ok := strings.CutPrefix(layerKey, dccache.prefix); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
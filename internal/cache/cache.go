@@ -7,34 +7,46 @@ import (
 	"time"
 
 	"github.com/NorskHelsenett/ror/pkg/helpers/kvcachehelper"
-	"github.com/NorskHelsenett/ror/pkg/helpers/kvcachehelper/memorycache"
 )
 
 var Cache *VitistackCache
 
 type VitistackCache struct {
-	cacheLayer kvcachehelper.CacheInterface
+	cacheLayer   kvcachehelper.CacheInterface
+	index        *keyIndex
+	mode         Mode
+	confirmation ConfirmationFunc
+	maxStaleness time.Duration
+	now          func() time.Time
+	prefix       string
+	redisOptions *RedisOptions
 }
 
-func (dccache VitistackCache) NewVitistackCache() (*VitistackCache, error) {
+func (dccache VitistackCache) NewVitistackCache(options ...Option) (*VitistackCache, error) {
 	dccache = VitistackCache{
-		cacheLayer: memorycache.NewKvCache(kvcachehelper.CacheOptions{
-			Timeout: time.Hour * 6,
-		}),
+		index: newKeyIndex(),
+		mode:  ModeJSON,
+		now:   time.Now,
+	}
+	for _, option := range options {
+		option(&dccache)
+	}
+	if err := dccache.newBackendLayer(); err != nil {
+		return nil, err
 	}
 	return &dccache, nil
 }
 
 func (dccache VitistackCache) Get(ctx context.Context, key string) (string, error) {
-	value, _ := dccache.cacheLayer.Get(ctx, key)
+	value, _, err := dccache.layerGet(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	recordLookup(value != nil)
 	if value == nil {
 		return "", nil
 	}
-	s, ok := value.(string)
-	if !ok {
-		return "", errors.New("cached value is not a string")
-	}
-	return s, nil
+	return encodeValue(value)
 }
 
 func (dccache VitistackCache) Set(ctx context.Context, key string, value any) error {
@@ -42,20 +54,28 @@ func (dccache VitistackCache) Set(ctx context.Context, key string, value any) er
 	if err != nil {
 		return err
 	}
-	dccache.cacheLayer.Set(ctx, key, string(stringvalue))
-	return nil
+	return dccache.layerSet(ctx, key, string(stringvalue))
 }
 
+// Delete removes the key. A key that is not cached is not an error: with a shared backend another
+// replica or an eviction may already have removed it.
 func (dccache VitistackCache) Delete(ctx context.Context, key string) error {
-	ok := dccache.cacheLayer.Remove(ctx, key)
-	if !ok {
+	removed, err := dccache.layerRemove(ctx, key)
+	if err != nil || removed {
+		return err
+	}
+	_, exists, err := dccache.layerGet(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
 		return errors.New("could not delete key")
 	}
 	return nil
 }
 
 func (dccache VitistackCache) Keys(ctx context.Context) ([]string, error) {
-	keys, err := dccache.cacheLayer.Keys(ctx)
+	keys, err := dccache.layerKeys(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -66,16 +86,16 @@ func (dccache VitistackCache) Keys(ctx context.Context) ([]string, error) {
 }
 
 func (dccache VitistackCache) GetByKey(ctx context.Context, key string) (string, error) {
-	value, ok := dccache.cacheLayer.Get(ctx, key)
+	value, ok, err := dccache.layerGet(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	recordLookup(ok)
 	if !ok {
 		return "", errors.New("key not found")
 	}
 	if value == nil {
 		return "", nil
 	}
-	s, ok := value.(string)
-	if !ok {
-		return "", errors.New("cached value is not a string")
-	}
-	return s, nil
+	return encodeValue(value)
 }
@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/NorskHelsenett/ror/pkg/helpers/kvcachehelper"
 )
 
 // Mock implementation for testing
-func NewMockVitistackCache() *VitistackCache {
-	mockCache := &VitistackCache{}
+func NewMockVitistackCache(options ...Option) *VitistackCache {
+	mockCache := &VitistackCache{index: newKeyIndex(), mode: ModeJSON, now: time.Now}
+	for _, option := range options {
+		option(mockCache)
+	}
 	mockCache.cacheLayer = &mockCacheLayer{
 		data: make(map[string]any),
 	}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrStale is returned for cached objects that were last confirmed longer ago than the cache's
+// maximum staleness
+var ErrStale = errors.New("cached object is stale")
+
+// ConfirmationFunc returns the last time every cached object of a resource was known to be
+// current, typically from the resource's watcher. The second return value is false if there is
+// no such time, in which case objects are only confirmed by their own events.
+type ConfirmationFunc func(resource schema.GroupVersionResource) (time.Time, bool)
+
+// WithConfirmation sets how the cache learns that the objects of a resource are still current
+// while no events arrive for them
+func WithConfirmation(confirmation ConfirmationFunc) Option {
+	return func(c *VitistackCache) {
+		c.confirmation = confirmation
+	}
+}
+
+// WithMaxStaleness makes the cache refuse objects last confirmed longer ago than maxStaleness.
+// 0 serves objects of any age.
+func WithMaxStaleness(maxStaleness time.Duration) Option {
+	return func(c *VitistackCache) {
+		c.maxStaleness = maxStaleness
+	}
+}
+
+// resourceConfirmedAt returns the last time every object of a resource was known to be current
+func (dccache VitistackCache) resourceConfirmedAt(resource schema.GroupVersionResource) time.Time {
+	if dccache.confirmation == nil {
+		return time.Time{}
+	}
+	confirmedAt, ok := dccache.confirmation(resource)
+	if !ok {
+		return time.Time{}
+	}
+	return confirmedAt
+}
+
+// confirmedAt returns the last time an object was known to be current: when it was last set by a
+// watch event or resync, or when its resource was last confirmed, whichever is later. Objects
+// restored from a snapshot are only confirmed by their own events, since the watcher may not
+// know about them.
+func confirmedAt(object indexedObject, resourceConfirmedAt time.Time) time.Time {
+	if !object.restored && resourceConfirmedAt.After(object.setAt) {
+		return resourceConfirmedAt
+	}
+	return object.setAt
+}
+
+// Staleness returns how long ago the object with the given key was last confirmed.
+// The second return value is false if the object is not cached.
+func (dccache VitistackCache) Staleness(key Key) (time.Duration, bool) {
+	object, ok := dccache.index.get(key)
+	if !ok {
+		return 0, false
+	}
+	return dccache.now().Sub(confirmedAt(object, dccache.resourceConfirmedAt(key.GroupVersionResource()))), true
+}
+
+// checkFresh returns ErrStale if the object with the given key is older than the maximum staleness
+func (dccache VitistackCache) checkFresh(key Key) error {
+	if dccache.maxStaleness <= 0 {
+		return nil
+	}
+	staleness, ok := dccache.Staleness(key)
+	if ok && staleness > dccache.maxStaleness {
+		return fmt.Errorf("%w: %s was last confirmed %s ago", ErrStale, key, staleness.Round(time.Second))
+	}
+	return nil
+}
+
+// CheckResourceFresh returns ErrStale if the objects of a resource, read straight from its
+// watcher's store instead of the cache, were last confirmed longer ago than the maximum staleness.
+// A store that can not be confirmed at all is stale too.
+func (dccache VitistackCache) CheckResourceFresh(resource schema.GroupVersionResource) error {
+	if dccache.maxStaleness <= 0 || dccache.confirmation == nil {
+		return nil
+	}
+	confirmedAt, ok := dccache.confirmation(resource)
+	if !ok {
+		return fmt.Errorf("%w: %s has not been confirmed", ErrStale, resource)
+	}
+	if staleness := dccache.now().Sub(confirmedAt); staleness > dccache.maxStaleness {
+		return fmt.Errorf("%w: %s was last confirmed %s ago", ErrStale, resource, staleness.Round(time.Second))
+	}
+	return nil
+}
+
+// ResourceFreshness reports how current the cached objects of one resource are
+type ResourceFreshness struct {
+	Resource string `json:"resource"`
+	Entries  int    `json:"entries"`
+	// OldestConfirmedAt is when the least recently confirmed object was last confirmed
+	OldestConfirmedAt time.Time `json:"oldestConfirmedAt"`
+	StalenessSeconds  float64   `json:"stalenessSeconds"`
+	// Stale is the number of objects the cache refuses to serve, always 0 without a maximum staleness
+	Stale int `json:"stale"`
+	// Restored is the number of objects loaded from a snapshot that the watcher has not confirmed yet
+	Restored int `json:"restored"`
+}
+
+// Freshness returns the freshness of the cached objects of every resource, sorted by resource
+func (dccache VitistackCache) Freshness() []ResourceFreshness {
+	now := dccache.now()
+	freshness := make([]ResourceFreshness, 0)
+	for resource, objects := range dccache.index.objectsByResource() {
+		report := ResourceFreshness{Resource: resource.String(), Entries: len(objects)}
+		resourceConfirmedAt := dccache.resourceConfirmedAt(resource)
+		for _, object := range objects {
+			if object.restored {
+				report.Restored++
+			}
+			objectConfirmedAt := confirmedAt(object, resourceConfirmedAt)
+			if report.OldestConfirmedAt.IsZero() || objectConfirmedAt.Before(report.OldestConfirmedAt) {
+				report.OldestConfirmedAt = objectConfirmedAt
+			}
+			if dccache.maxStaleness > 0 && now.Sub(objectConfirmedAt) > dccache.maxStaleness {
+				report.Stale++
+			}
+		}
+		report.StalenessSeconds = now.Sub(report.OldestConfirmedAt).Seconds()
+		freshness = append(freshness, report)
+	}
+	slices.SortFunc(freshness, func(a, b ResourceFreshness) int {
+		return strings.Compare(a.Resource, b.Resource)
+	})
+	return freshness
+}
+
+// MaxStaleness returns the age beyond which cached objects are refused, 0 if there is no bound
+func (dccache VitistackCache) MaxStaleness() time.Duration {
+	return dccache.maxStaleness
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// testClock is a clock that only moves when told to
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newFreshnessTestCache(clock *testClock, options ...Option) *VitistackCache {
+	c := NewMockVitistackCache(options...)
+	c.now = clock.Now
+	return c
+}
+
+func TestStaleness(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newFreshnessTestCache(clock)
+	key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+
+	clock.now = clock.now.Add(time.Hour)
+	if staleness, ok := c.Staleness(key); !ok || staleness != time.Hour {
+		t.Errorf("Staleness = %v, %v, want 1h", staleness, ok)
+	}
+
+	// A resync or watch event confirms the object again
+	setConfigMap(t, c, newConfigMap("default", "config", "one"))
+	if staleness, _ := c.Staleness(key); staleness != 0 {
+		t.Errorf("Staleness after resync = %v, want 0", staleness)
+	}
+
+	if _, ok := c.Staleness(ObjectKey(configMapsGVR, "default", "missing")); ok {
+		t.Error("Staleness of an object that is not cached should not be reported")
+	}
+}
+
+func TestConfirmationKeepsQuietObjectsFresh(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	watcherConfirmedAt := clock.now
+	confirmation := func(resource schema.GroupVersionResource) (time.Time, bool) {
+		return watcherConfirmedAt, resource == configMapsGVR
+	}
+	c := newFreshnessTestCache(clock, WithConfirmation(confirmation), WithMaxStaleness(10*time.Minute))
+	key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+
+	// The object gets no events for a day, but its watcher stays synced
+	clock.now = clock.now.Add(24 * time.Hour)
+	watcherConfirmedAt = clock.now
+	if _, _, err := GetAs[corev1.ConfigMap](context.Background(), c, key); err != nil {
+		t.Fatalf("GetAs of an object confirmed by its watcher failed: %v", err)
+	}
+
+	// The watcher fails and stops confirming the object
+	clock.now = clock.now.Add(11 * time.Minute)
+	if staleness, _ := c.Staleness(key); staleness != 11*time.Minute {
+		t.Errorf("Staleness = %v, want 11m", staleness)
+	}
+	if _, _, err := GetAs[corev1.ConfigMap](context.Background(), c, key); !errors.Is(err, ErrStale) {
+		t.Errorf("GetAs of a stale object = %v, want ErrStale", err)
+	}
+}
+
+func TestMaxStalenessRefusesStaleObjects(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newFreshnessTestCache(clock, WithMaxStaleness(time.Minute))
+	key := setConfigMap(t, c, newConfigMap("default", "old", "one"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	setConfigMap(t, c, newConfigMap("default", "new", "two"))
+
+	if _, err := c.GetObject(ctx, key); !errors.Is(err, ErrStale) {
+		t.Errorf("GetObject = %v, want ErrStale", err)
+	}
+	if _, err := ListAs[corev1.ConfigMap](ctx, c, Query{Kind: "ConfigMap"}); !errors.Is(err, ErrStale) {
+		t.Errorf("ListAs = %v, want ErrStale", err)
+	}
+	if configMaps, err := ListAs[corev1.ConfigMap](ctx, c, Query{Kind: "ConfigMap", Name: "new"}); err != nil || len(configMaps) != 1 {
+		t.Errorf("ListAs of the fresh object = %v, %v", configMaps, err)
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newFreshnessTestCache(clock, WithMaxStaleness(time.Minute))
+	setConfigMap(t, c, newConfigMap("default", "old", "one"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	setConfigMap(t, c, newConfigMap("default", "new", "two"))
+
+	freshness := c.Freshness()
+	if len(freshness) != 1 {
+		t.Fatalf("Freshness returned %d resources, want 1", len(freshness))
+	}
+	report := freshness[0]
+	if report.Resource != configMapsGVR.String() || report.Entries != 2 || report.Stale != 1 || report.StalenessSeconds != 120 {
+		t.Errorf("Freshness = %+v", report)
+	}
+}
+
+func TestCheckResourceFresh(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	watcherConfirmedAt := clock.now
+	confirmation := func(resource schema.GroupVersionResource) (time.Time, bool) {
+		return watcherConfirmedAt, resource == configMapsGVR
+	}
+	c := newFreshnessTestCache(clock, WithConfirmation(confirmation), WithMaxStaleness(10*time.Minute))
+
+	clock.now = clock.now.Add(5 * time.Minute)
+	if err := c.CheckResourceFresh(configMapsGVR); err != nil {
+		t.Errorf("CheckResourceFresh of a recently confirmed store = %v, want nil", err)
+	}
+
+	// The watcher started failing and its store has not been confirmed since
+	clock.now = clock.now.Add(time.Hour)
+	if err := c.CheckResourceFresh(configMapsGVR); !errors.Is(err, ErrStale) {
+		t.Errorf("CheckResourceFresh of a store confirmed an hour ago = %v, want ErrStale", err)
+	}
+
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	if err := c.CheckResourceFresh(secrets); !errors.Is(err, ErrStale) {
+		t.Errorf("CheckResourceFresh of an unconfirmed store = %v, want ErrStale", err)
+	}
+
+	if err := newFreshnessTestCache(clock, WithConfirmation(confirmation)).CheckResourceFresh(secrets); err != nil {
+		t.Errorf("CheckResourceFresh without a maximum staleness = %v, want nil", err)
+	}
+}
@@ -0,0 +1,354 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// ObjectMeta is the metadata of a cached object that the cache indexes
+type ObjectMeta struct {
+	UID    string
+	Kind   string
+	Labels map[string]string
+}
+
+// indexedObject is the index entry of a cached object
+type indexedObject struct {
+	ObjectMeta
+	size int
+	// setAt is when the object was last stored, by a watch event or a resync
+	setAt time.Time
+	// restored objects were loaded from a snapshot and have not been confirmed by their watcher since
+	restored bool
+}
+
+// keySet is a set of cache keys
+type keySet map[Key]struct{}
+
+func (s keySet) keys() []Key {
+	keys := make([]Key, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// keyIndex indexes the keys of the cached objects by UID, resource, kind, namespace, name and labels
+type keyIndex struct {
+	mutex       sync.RWMutex
+	objects     map[Key]indexedObject
+	byUID       map[string]Key
+	byResource  map[schema.GroupVersionResource]keySet
+	byKind      map[string]keySet
+	byNamespace map[string]keySet
+	byName      map[string]keySet
+	// byLabel maps a label key to the keys of the objects with each of its values
+	byLabel map[string]map[string]keySet
+	// bytesByKind is the approximate memory held by the cached objects of each kind
+	bytesByKind map[string]int
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{
+		objects:     make(map[Key]indexedObject),
+		byUID:       make(map[string]Key),
+		byResource:  make(map[schema.GroupVersionResource]keySet),
+		byKind:      make(map[string]keySet),
+		byNamespace: make(map[string]keySet),
+		byName:      make(map[string]keySet),
+		byLabel:     make(map[string]map[string]keySet),
+		bytesByKind: make(map[string]int),
+	}
+}
+
+func addKey[K comparable](index map[K]keySet, value K, key Key) {
+	if index[value] == nil {
+		index[value] = make(keySet)
+	}
+	index[value][key] = struct{}{}
+}
+
+func removeKey[K comparable](index map[K]keySet, value K, key Key) {
+	delete(index[value], key)
+	if len(index[value]) == 0 {
+		delete(index, value)
+	}
+}
+
+// add indexes the object under key, replacing the index entries of the previous version of it
+func (i *keyIndex) add(key Key, object indexedObject) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	// The object may have been recreated with a new UID, or had its labels changed
+	i.removeLocked(key)
+
+	meta := object.ObjectMeta
+	i.objects[key] = object
+	i.bytesByKind[meta.Kind] += object.size
+	cacheBytes.WithLabelValues(meta.Kind).Set(float64(i.bytesByKind[meta.Kind]))
+	if meta.UID != "" {
+		i.byUID[meta.UID] = key
+	}
+	addKey(i.byResource, key.GroupVersionResource(), key)
+	addKey(i.byKind, meta.Kind, key)
+	addKey(i.byNamespace, key.Namespace, key)
+	addKey(i.byName, key.Name, key)
+	for name, value := range meta.Labels {
+		if i.byLabel[name] == nil {
+			i.byLabel[name] = make(map[string]keySet)
+		}
+		addKey(i.byLabel[name], value, key)
+	}
+}
+
+func (i *keyIndex) remove(key Key) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.removeLocked(key)
+}
+
+func (i *keyIndex) removeLocked(key Key) {
+	meta, ok := i.objects[key]
+	if !ok {
+		return
+	}
+
+	delete(i.objects, key)
+	i.bytesByKind[meta.Kind] -= meta.size
+	cacheBytes.WithLabelValues(meta.Kind).Set(float64(i.bytesByKind[meta.Kind]))
+	if i.bytesByKind[meta.Kind] == 0 {
+		delete(i.bytesByKind, meta.Kind)
+	}
+	if meta.UID != "" && i.byUID[meta.UID] == key {
+		delete(i.byUID, meta.UID)
+	}
+	removeKey(i.byResource, key.GroupVersionResource(), key)
+	removeKey(i.byKind, meta.Kind, key)
+	removeKey(i.byNamespace, key.Namespace, key)
+	removeKey(i.byName, key.Name, key)
+	for name, value := range meta.Labels {
+		removeKey(i.byLabel[name], value, key)
+		if len(i.byLabel[name]) == 0 {
+			delete(i.byLabel, name)
+		}
+	}
+}
+
+func (i *keyIndex) keyForUID(uid string) (Key, bool) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	key, ok := i.byUID[uid]
+	return key, ok
+}
+
+func (i *keyIndex) get(key Key) (indexedObject, bool) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	object, ok := i.objects[key]
+	return object, ok
+}
+
+// len returns the number of indexed objects
+func (i *keyIndex) len() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return len(i.objects)
+}
+
+// objectsByResource returns the index entries of the objects, grouped by resource
+func (i *keyIndex) objectsByResource() map[schema.GroupVersionResource]map[Key]indexedObject {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	objects := make(map[schema.GroupVersionResource]map[Key]indexedObject, len(i.byResource))
+	for resource, keys := range i.byResource {
+		objects[resource] = make(map[Key]indexedObject, len(keys))
+		for key := range keys {
+			objects[resource][key] = i.objects[key]
+		}
+	}
+	return objects
+}
+
+// restoredKeys returns the keys of the restored objects of a resource
+func (i *keyIndex) restoredKeys(resource schema.GroupVersionResource) []Key {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	keys := make([]Key, 0)
+	for key := range i.byResource[resource] {
+		if i.objects[key].restored {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (i *keyIndex) memoryByKind() map[string]int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return maps.Clone(i.bytesByKind)
+}
+
+func (i *keyIndex) kindStats() []KindStats {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	stats := make([]KindStats, 0, len(i.byKind))
+	for kind, keys := range i.byKind {
+		stats = append(stats, KindStats{Kind: kind, Entries: len(keys), Bytes: i.bytesByKind[kind]})
+	}
+	return stats
+}
+
+func (i *keyIndex) keysForResource(resource schema.GroupVersionResource) []Key {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return sortKeys(i.byResource[resource].keys())
+}
+
+// find returns the sorted keys of the objects matching the query. It starts from the smallest
+// index set the query selects and checks the remaining conditions on those keys only.
+func (i *keyIndex) find(query Query) []Key {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	candidates, indexed := i.candidates(query)
+	if !indexed {
+		candidates = make(keySet, len(i.objects))
+		for key := range i.objects {
+			candidates[key] = struct{}{}
+		}
+	}
+
+	keys := make([]Key, 0, len(candidates))
+	for key := range candidates {
+		if query.matches(key, i.objects[key].ObjectMeta) {
+			keys = append(keys, key)
+		}
+	}
+	return sortKeys(keys)
+}
+
+// candidates returns the smallest index set selected by the query. The second return value is
+// false if the query selects no index, in which case every object is a candidate.
+func (i *keyIndex) candidates(query Query) (keySet, bool) {
+	var smallest keySet
+	indexed := false
+	consider := func(set keySet) {
+		if !indexed || len(set) < len(smallest) {
+			smallest = set
+			indexed = true
+		}
+	}
+
+	if !query.Resource.Empty() {
+		consider(i.byResource[query.Resource])
+	}
+	if query.Kind != "" {
+		consider(i.byKind[query.Kind])
+	}
+	if query.Namespace != "" {
+		consider(i.byNamespace[query.Namespace])
+	}
+	if query.Name != "" {
+		consider(i.byName[query.Name])
+	}
+	if query.LabelSelector != nil {
+		requirements, _ := query.LabelSelector.Requirements()
+		for _, requirement := range requirements {
+			switch requirement.Operator() {
+			case selection.Equals, selection.DoubleEquals:
+				value, _ := requirement.Values().PopAny()
+				consider(i.byLabel[requirement.Key()][value])
+			case selection.In:
+				// Only a single value selects one index set
+				if requirement.Values().Len() == 1 {
+					value, _ := requirement.Values().PopAny()
+					consider(i.byLabel[requirement.Key()][value])
+				}
+			}
+		}
+	}
+	return smallest, indexed
+}
+
+func sortKeys(keys []Key) []Key {
+	slices.SortFunc(keys, func(a, b Key) int {
+		return cmp.Or(
+			strings.Compare(a.Group, b.Group),
+			strings.Compare(a.Version, b.Version),
+			strings.Compare(a.Resource, b.Resource),
+			strings.Compare(a.Namespace, b.Namespace),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+	return keys
+}
+
+// Query selects cached objects. Empty fields match every object.
+type Query struct {
+	Resource schema.GroupVersionResource
+	Kind     string
+	// Namespace limits the query to one namespace. Cluster scoped objects can not be selected
+	// on their own, since an empty namespace matches every object.
+	Namespace     string
+	Name          string
+	LabelSelector labels.Selector
+}
+
+func (q Query) matches(key Key, meta ObjectMeta) bool {
+	if !q.Resource.Empty() && key.GroupVersionResource() != q.Resource {
+		return false
+	}
+	if q.Kind != "" && meta.Kind != q.Kind {
+		return false
+	}
+	if q.Namespace != "" && key.Namespace != q.Namespace {
+		return false
+	}
+	if q.Name != "" && key.Name != q.Name {
+		return false
+	}
+	if q.LabelSelector != nil && !q.LabelSelector.Matches(labels.Set(meta.Labels)) {
+		return false
+	}
+	return true
+}
+
+// MemoryByKind returns the approximate memory in bytes held by the cached objects of each kind
+func (dccache VitistackCache) MemoryByKind() map[string]int {
+	return dccache.index.memoryByKind()
+}
+
+// Find returns the sorted keys of the cached objects matching the query
+func (dccache VitistackCache) Find(query Query) []Key {
+	return dccache.index.find(query)
+}
+
+// List returns the JSON of the cached objects matching the query, in key order.
+// Objects that expired from the cache since they were indexed are left out.
+func (dccache VitistackCache) List(ctx context.Context, query Query) ([]string, error) {
+	keys := dccache.Find(query)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := dccache.GetObject(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var providersGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineproviders"}
+
+type testObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func setTestObject(t testing.TB, c *VitistackCache, key Key, meta ObjectMeta) {
+	t.Helper()
+	if err := c.SetObject(context.Background(), key, meta, testObject{Kind: meta.Kind, Name: key.Name}); err != nil {
+		t.Fatalf("SetObject failed: %v", err)
+	}
+}
+
+func keyNames(keys []Key) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	return names
+}
+
+func TestFind(t *testing.T) {
+	c := NewMockVitistackCache()
+	setTestObject(t, c, ObjectKey(machinesGVR, "team-a", "machine-1"), ObjectMeta{UID: "1", Kind: "Machine", Labels: map[string]string{"cluster": "one"}})
+	setTestObject(t, c, ObjectKey(machinesGVR, "team-a", "machine-2"), ObjectMeta{UID: "2", Kind: "Machine", Labels: map[string]string{"cluster": "two"}})
+	setTestObject(t, c, ObjectKey(machinesGVR, "team-b", "machine-3"), ObjectMeta{UID: "3", Kind: "Machine", Labels: map[string]string{"cluster": "one"}})
+	setTestObject(t, c, ObjectKey(providersGVR, "", "provider-1"), ObjectMeta{UID: "4", Kind: "MachineProvider"})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"everything", Query{}, []string{"provider-1", "machine-1", "machine-2", "machine-3"}},
+		{"kind", Query{Kind: "MachineProvider"}, []string{"provider-1"}},
+		{"resource", Query{Resource: machinesGVR}, []string{"machine-1", "machine-2", "machine-3"}},
+		{"namespace", Query{Namespace: "team-a"}, []string{"machine-1", "machine-2"}},
+		{"name", Query{Kind: "Machine", Name: "machine-3"}, []string{"machine-3"}},
+		{"label", Query{LabelSelector: labels.SelectorFromSet(labels.Set{"cluster": "one"})}, []string{"machine-1", "machine-3"}},
+		{"label and namespace", Query{Namespace: "team-b", LabelSelector: labels.SelectorFromSet(labels.Set{"cluster": "one"})}, []string{"machine-3"}},
+		{"label not equal", Query{Kind: "Machine", LabelSelector: mustParseSelector(t, "cluster!=one")}, []string{"machine-2"}},
+		{"unknown kind", Query{Kind: "Unknown"}, []string{}},
+		{"unknown label value", Query{LabelSelector: labels.SelectorFromSet(labels.Set{"cluster": "three"})}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keyNames(c.Find(tt.query))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseSelector(t *testing.T, value string) labels.Selector {
+	t.Helper()
+	selector, err := labels.Parse(value)
+	if err != nil {
+		t.Fatalf("labels.Parse(%q) failed: %v", value, err)
+	}
+	return selector
+}
+
+func TestFindAfterRelabelAndDelete(t *testing.T) {
+	c := NewMockVitistackCache()
+	key := ObjectKey(machinesGVR, "team-a", "machine-1")
+	one := labels.SelectorFromSet(labels.Set{"cluster": "one"})
+	two := labels.SelectorFromSet(labels.Set{"cluster": "two"})
+
+	setTestObject(t, c, key, ObjectMeta{UID: "1", Kind: "Machine", Labels: map[string]string{"cluster": "one"}})
+	setTestObject(t, c, key, ObjectMeta{UID: "1", Kind: "Machine", Labels: map[string]string{"cluster": "two"}})
+
+	if keys := c.Find(Query{LabelSelector: one}); len(keys) != 0 {
+		t.Errorf("Find with the old label = %v, want none", keys)
+	}
+	if keys := c.Find(Query{LabelSelector: two}); len(keys) != 1 {
+		t.Errorf("Find with the new label = %v, want the object", keys)
+	}
+
+	if err := c.DeleteObject(context.Background(), key); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	for _, query := range []Query{{Kind: "Machine"}, {Namespace: "team-a"}, {Name: "machine-1"}, {LabelSelector: two}} {
+		if keys := c.Find(query); len(keys) != 0 {
+			t.Errorf("Find(%+v) after delete = %v, want none", query, keys)
+		}
+	}
+	if len(c.index.byKind) != 0 || len(c.index.byLabel) != 0 || len(c.index.byNamespace) != 0 {
+		t.Error("empty index sets should be removed")
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache()
+	setTestObject(t, c, ObjectKey(providersGVR, "", "provider-1"), ObjectMeta{UID: "1", Kind: "MachineProvider"})
+	setTestObject(t, c, ObjectKey(machinesGVR, "team-a", "machine-1"), ObjectMeta{UID: "2", Kind: "Machine"})
+
+	values, err := c.List(ctx, Query{Kind: "MachineProvider"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(values) != 1 || values[0] != `{"kind":"MachineProvider","name":"provider-1"}` {
+		t.Errorf("List = %v", values)
+	}
+}
+
+// populateInventory caches machines machines spread over ten namespaces and ten providers
+func populateInventory(b *testing.B, machines int) *VitistackCache {
+	c := NewMockVitistackCache()
+	for i := range machines {
+		setTestObject(b, c, ObjectKey(machinesGVR, fmt.Sprintf("team-%d", i%10), fmt.Sprintf("machine-%d", i)), ObjectMeta{
+			UID:    fmt.Sprintf("machine-uid-%d", i),
+			Kind:   "Machine",
+			Labels: map[string]string{"cluster": fmt.Sprintf("cluster-%d", i%100)},
+		})
+	}
+	for i := range 10 {
+		setTestObject(b, c, ObjectKey(providersGVR, "", fmt.Sprintf("provider-%d", i)), ObjectMeta{UID: fmt.Sprintf("provider-uid-%d", i), Kind: "MachineProvider"})
+	}
+	return c
+}
+
+// BenchmarkListByKindScan lists the providers the way the repositories did before the cache
+// had indexes, unmarshalling every cached object to check its kind
+func BenchmarkListByKindScan(b *testing.B) {
+	for _, machines := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("machines=%d", machines), func(b *testing.B) {
+			ctx := context.Background()
+			c := populateInventory(b, machines)
+			b.ResetTimer()
+			for range b.N {
+				keys, _ := c.Keys(ctx)
+				found := 0
+				for _, key := range keys {
+					value, _ := c.Get(ctx, key)
+					var object testObject
+					if json.Unmarshal([]byte(value), &object) == nil && object.Kind == "MachineProvider" {
+						found++
+					}
+				}
+				if found != 10 {
+					b.Fatalf("found %d providers, want 10", found)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListByKindIndexed(b *testing.B) {
+	for _, machines := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("machines=%d", machines), func(b *testing.B) {
+			ctx := context.Background()
+			c := populateInventory(b, machines)
+			b.ResetTimer()
+			for range b.N {
+				values, _ := c.List(ctx, Query{Kind: "MachineProvider"})
+				if len(values) != 10 {
+					b.Fatalf("found %d providers, want 10", len(values))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFindByLabel(b *testing.B) {
+	c := populateInventory(b, 10000)
+	query := Query{Kind: "Machine", LabelSelector: labels.SelectorFromSet(labels.Set{"cluster": "cluster-7"})}
+	b.ResetTimer()
+	for range b.N {
+		if keys := c.Find(query); len(keys) != 100 {
+			b.Fatalf("found %d machines, want 100", len(keys))
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// EntryInfo describes a cached object without its content
+type EntryInfo struct {
+	Key       string            `json:"key"`
+	Resource  string            `json:"resource"`
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	UID       string            `json:"uid,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	SizeBytes int               `json:"sizeBytes"`
+	// SetAt is when the object was last stored, by a watch event, a resync or a snapshot
+	SetAt            time.Time `json:"setAt"`
+	StalenessSeconds float64   `json:"stalenessSeconds"`
+	Restored         bool      `json:"restored,omitempty"`
+}
+
+// KindStats is the number of cached objects of a kind and the approximate memory they hold
+type KindStats struct {
+	Kind    string `json:"kind"`
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+}
+
+// entryInfo describes the cached object with the given key. The second return value is false if
+// the object is not cached.
+func (dccache VitistackCache) entryInfo(key Key) (EntryInfo, bool) {
+	object, ok := dccache.index.get(key)
+	if !ok {
+		return EntryInfo{}, false
+	}
+	staleness := dccache.now().Sub(confirmedAt(object, dccache.resourceConfirmedAt(key.GroupVersionResource())))
+	return EntryInfo{
+		Key:              key.String(),
+		Resource:         key.GroupVersionResource().String(),
+		Kind:             object.Kind,
+		Namespace:        key.Namespace,
+		Name:             key.Name,
+		UID:              object.UID,
+		Labels:           object.Labels,
+		SizeBytes:        object.size,
+		SetAt:            object.setAt,
+		StalenessSeconds: staleness.Seconds(),
+		Restored:         object.restored,
+	}, true
+}
+
+// Contains reports whether an object is cached under the given key
+func (dccache VitistackCache) Contains(key Key) bool {
+	_, ok := dccache.index.get(key)
+	return ok
+}
+
+// Entries describes the cached objects matching the query, sorted by key
+func (dccache VitistackCache) Entries(query Query) []EntryInfo {
+	entries := make([]EntryInfo, 0)
+	for _, key := range dccache.index.find(query) {
+		if entry, ok := dccache.entryInfo(key); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Entry returns the description and the JSON of the cached object with the given key, however
+// stale it is. The last return value is false if the object is not cached.
+func (dccache VitistackCache) Entry(ctx context.Context, key Key) (EntryInfo, string, bool, error) {
+	entry, ok := dccache.entryInfo(key)
+	if !ok {
+		return EntryInfo{}, "", false, nil
+	}
+	value, ok, err := dccache.layerGet(ctx, key.String())
+	if err != nil || !ok || value == nil {
+		return EntryInfo{}, "", false, err
+	}
+	encoded, err := encodeValue(value)
+	if err != nil {
+		return EntryInfo{}, "", false, err
+	}
+	return entry, encoded, true, nil
+}
+
+// Stats returns the number of cached objects and the approximate memory they hold for every kind,
+// sorted by kind
+func (dccache VitistackCache) Stats() []KindStats {
+	stats := dccache.index.kindStats()
+	slices.SortFunc(stats, func(a, b KindStats) int {
+		return cmp.Compare(a.Kind, b.Kind)
+	})
+	return stats
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEntries(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newFreshnessTestCache(clock)
+	first := setConfigMap(t, c, newConfigMap("default", "config-1", "one"))
+	setConfigMap(t, c, newConfigMap("other", "config-2", "two"))
+	clock.now = clock.now.Add(time.Minute)
+
+	entries := c.Entries(Query{Namespace: "default"})
+	if len(entries) != 1 {
+		t.Fatalf("Entries returned %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Key != first.String() || entry.Kind != "ConfigMap" || entry.Namespace != "default" || entry.Name != "config-1" ||
+		entry.UID != "uid-config-1" || entry.SizeBytes <= 0 || entry.StalenessSeconds != 60 {
+		t.Errorf("Entries = %+v", entry)
+	}
+
+	if entries := c.Entries(Query{}); len(entries) != 2 {
+		t.Errorf("Entries without a query returned %d entries, want 2", len(entries))
+	}
+}
+
+func TestEntryIgnoresStaleness(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, mode := range []Mode{ModeJSON, ModeObject} {
+		c := newFreshnessTestCache(clock, WithMode(mode), WithMaxStaleness(time.Minute))
+		key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+		clock.now = clock.now.Add(time.Hour)
+
+		entry, value, found, err := c.Entry(ctx, key)
+		if err != nil || !found || entry.Name != "config" {
+			t.Fatalf("Entry in %s mode = %+v, %v, %v", mode, entry, found, err)
+		}
+		var content map[string]any
+		if err := json.Unmarshal([]byte(value), &content); err != nil || content["data"].(map[string]any)["value"] != "one" {
+			t.Errorf("Entry value in %s mode = %s, %v", mode, value, err)
+		}
+
+		if _, _, found, _ := c.Entry(ctx, ObjectKey(configMapsGVR, "default", "missing")); found {
+			t.Errorf("Entry of an object that is not cached should not be found")
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := NewMockVitistackCache()
+	setConfigMap(t, c, newConfigMap("default", "config-1", "one"))
+	setConfigMap(t, c, newConfigMap("default", "config-2", "two"))
+
+	stats := c.Stats()
+	if len(stats) != 1 || stats[0].Kind != "ConfigMap" || stats[0].Entries != 2 || stats[0].Bytes != c.MemoryByKind()["ConfigMap"] {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
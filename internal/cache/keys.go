@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Key is the canonical cache key of an object, written as group/version/resource/namespace/name.
+// The group of core resources and the namespace of cluster scoped objects are empty.
+type Key struct {
+	Group     string
+	Version   string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// ObjectKey returns the cache key of the object with the given namespace and name
+func ObjectKey(resource schema.GroupVersionResource, namespace, name string) Key {
+	return Key{
+		Group:     resource.Group,
+		Version:   resource.Version,
+		Resource:  resource.Resource,
+		Namespace: namespace,
+		Name:      name,
+	}
+}
+
+// ParseKey parses a key written by Key.String
+func ParseKey(value string) (Key, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 5 || parts[1] == "" || parts[2] == "" || parts[4] == "" {
+		return Key{}, fmt.Errorf("invalid cache key %q, expected group/version/resource/namespace/name", value)
+	}
+	return Key{Group: parts[0], Version: parts[1], Resource: parts[2], Namespace: parts[3], Name: parts[4]}, nil
+}
+
+func (k Key) String() string {
+	return strings.Join([]string{k.Group, k.Version, k.Resource, k.Namespace, k.Name}, "/")
+}
+
+// GroupVersionResource returns the resource of the object
+func (k Key) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: k.Group, Version: k.Version, Resource: k.Resource}
+}
+
+// SetObject stores the object under its key and indexes it by resource and by the given metadata.
+// In ModeObject Kubernetes objects are stored decoded, otherwise as JSON.
+func (dccache VitistackCache) SetObject(ctx context.Context, key Key, meta ObjectMeta, value any) error {
+	return dccache.storeObject(ctx, key, indexedObject{ObjectMeta: meta, setAt: dccache.now()}, value)
+}
+
+// storeObject stores the object under its key and indexes it with the given index entry
+func (dccache VitistackCache) storeObject(ctx context.Context, key Key, object indexedObject, value any) error {
+	stored, size, err := dccache.storedValue(object.Kind, value)
+	if err != nil {
+		return err
+	}
+	object.size = size
+	if err := dccache.layerSet(ctx, key.String(), stored); err != nil {
+		return err
+	}
+	dccache.index.add(key, object)
+	return nil
+}
+
+// GetObject returns the JSON of the object with the given key, or an empty string if it is not cached.
+// It returns ErrStale if the object is older than the maximum staleness.
+func (dccache VitistackCache) GetObject(ctx context.Context, key Key) (string, error) {
+	if err := dccache.checkFresh(key); err != nil {
+		return "", err
+	}
+	return dccache.Get(ctx, key.String())
+}
+
+// DeleteObject removes the object with the given key and its index entries. Deleting an object that
+// is not cached is not an error.
+func (dccache VitistackCache) DeleteObject(ctx context.Context, key Key) error {
+	dccache.index.remove(key)
+	return dccache.Delete(ctx, key.String())
+}
+
+// GetByUID returns the JSON of the object with the given UID, or an empty string if it is not cached
+func (dccache VitistackCache) GetByUID(ctx context.Context, uid string) (string, error) {
+	key, ok := dccache.index.keyForUID(uid)
+	if !ok {
+		recordLookup(false)
+		return "", nil
+	}
+	return dccache.GetObject(ctx, key)
+}
+
+// KeyForUID returns the key of the object with the given UID
+func (dccache VitistackCache) KeyForUID(uid string) (Key, bool) {
+	return dccache.index.keyForUID(uid)
+}
+
+// KeysForResource returns the keys of the cached objects of a resource, sorted
+func (dccache VitistackCache) KeysForResource(resource schema.GroupVersionResource) []Key {
+	return dccache.index.keysForResource(resource)
+}
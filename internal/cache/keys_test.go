@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var machinesGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+
+func TestKeyStringRoundTrip(t *testing.T) {
+	tests := []Key{
+		ObjectKey(machinesGVR, "default", "machine-1"),
+		ObjectKey(machinesGVR, "", "cluster-scoped"),
+		ObjectKey(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "vitistack", "vitistack-name"),
+	}
+	for _, key := range tests {
+		parsed, err := ParseKey(key.String())
+		if err != nil {
+			t.Fatalf("ParseKey(%q) failed: %v", key.String(), err)
+		}
+		if parsed != key {
+			t.Errorf("ParseKey(%q) = %+v, want %+v", key.String(), parsed, key)
+		}
+	}
+
+	if got := ObjectKey(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ns", "cm").String(); got != "/v1/configmaps/ns/cm" {
+		t.Errorf("String() = %q, want /v1/configmaps/ns/cm", got)
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	for _, value := range []string{"", "uid-1", "vitistack.io/v1alpha1/machines/default", "vitistack.io//machines/default/name", "vitistack.io/v1alpha1/machines/default/"} {
+		if _, err := ParseKey(value); err == nil {
+			t.Errorf("ParseKey(%q) should fail", value)
+		}
+	}
+}
+
+func TestGetByUID(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache()
+	key := ObjectKey(machinesGVR, "default", "machine-1")
+
+	if err := c.SetObject(ctx, key, ObjectMeta{UID: "uid-1"}, map[string]string{"name": "machine-1"}); err != nil {
+		t.Fatalf("SetObject failed: %v", err)
+	}
+
+	value, err := c.GetByUID(ctx, "uid-1")
+	if err != nil || value != `{"name":"machine-1"}` {
+		t.Errorf("GetByUID = %q, %v", value, err)
+	}
+	if got, ok := c.KeyForUID("uid-1"); !ok || got != key {
+		t.Errorf("KeyForUID = %+v, %v", got, ok)
+	}
+
+	value, err = c.GetByUID(ctx, "unknown")
+	if err != nil || value != "" {
+		t.Errorf("GetByUID of an unknown uid = %q, %v", value, err)
+	}
+}
+
+func TestSetObjectReplacesUIDOfRecreatedObject(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache()
+	key := ObjectKey(machinesGVR, "default", "machine-1")
+
+	_ = c.SetObject(ctx, key, ObjectMeta{UID: "uid-1"}, "first")
+	_ = c.SetObject(ctx, key, ObjectMeta{UID: "uid-2"}, "second")
+
+	if _, ok := c.KeyForUID("uid-1"); ok {
+		t.Error("the uid of the deleted object should not be indexed")
+	}
+	if value, _ := c.GetByUID(ctx, "uid-2"); value != `"second"` {
+		t.Errorf("GetByUID = %q, want the recreated object", value)
+	}
+}
+
+func TestDeleteObjectRemovesIndexEntries(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache()
+	key := ObjectKey(machinesGVR, "default", "machine-1")
+
+	_ = c.SetObject(ctx, key, ObjectMeta{UID: "uid-1"}, "value")
+	if err := c.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	if _, ok := c.KeyForUID("uid-1"); ok {
+		t.Error("uid should not be indexed after delete")
+	}
+	if keys := c.KeysForResource(machinesGVR); len(keys) != 0 {
+		t.Errorf("KeysForResource = %v, want none", keys)
+	}
+	if value, _ := c.GetObject(ctx, key); value != "" {
+		t.Errorf("GetObject = %q, want empty", value)
+	}
+}
+
+func TestKeysForResource(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache()
+
+	_ = c.SetObject(ctx, ObjectKey(machinesGVR, "b", "machine-2"), ObjectMeta{UID: "uid-2"}, "value")
+	_ = c.SetObject(ctx, ObjectKey(machinesGVR, "a", "machine-1"), ObjectMeta{UID: "uid-1"}, "value")
+	_ = c.SetObject(ctx, ObjectKey(providersGVR, "", "provider-1"), ObjectMeta{UID: "uid-3"}, "value")
+
+	keys := c.KeysForResource(machinesGVR)
+	if len(keys) != 2 {
+		t.Fatalf("KeysForResource returned %d keys, want 2", len(keys))
+	}
+	if keys[0].Name != "machine-1" || keys[1].Name != "machine-2" {
+		t.Errorf("KeysForResource = %v, want sorted keys", keys)
+	}
+	if keys := c.KeysForResource(providersGVR); len(keys) != 1 || keys[0].Name != "provider-1" {
+		t.Errorf("KeysForResource = %v", keys)
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NorskHelsenett/ror/pkg/helpers/kvcachehelper"
+)
+
+// memoryLayer is an in-memory cache layer whose entries never expire. The informers keep the
+// cached objects current, so entries are only removed when their objects are deleted.
+type memoryLayer struct {
+	mutex sync.RWMutex
+	data  map[string]any
+}
+
+func newMemoryLayer() *memoryLayer {
+	return &memoryLayer{data: make(map[string]any)}
+}
+
+func (m *memoryLayer) Get(_ context.Context, key string, _ ...kvcachehelper.CacheGetOptions) (any, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, ok := m.data[key]
+	return value, ok
+}
+
+func (m *memoryLayer) Set(_ context.Context, key string, value any, _ ...kvcachehelper.CacheSetOptions) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.data[key] = value
+}
+
+func (m *memoryLayer) Keys(_ context.Context, _ ...kvcachehelper.CacheKeysOptions) ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	keys := make([]string, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *memoryLayer) Remove(_ context.Context, key string, _ ...kvcachehelper.CacheRemoveOptions) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.data[key]
+	delete(m.data, key)
+	return ok
+}
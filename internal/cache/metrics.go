@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "vitistack_operator",
+		Name:      "cache_hits_total",
+		Help:      "Cache lookups that found the key.",
+	})
+
+	cacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "vitistack_operator",
+		Name:      "cache_misses_total",
+		Help:      "Cache lookups that did not find the key.",
+	})
+
+	cacheBackendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vitistack_operator",
+		Name:      "cache_backend_errors_total",
+		Help:      "Cache backend operations that failed, such as on an unreachable Redis server. Failed lookups are not counted as misses.",
+	}, []string{"operation"})
+
+	cacheBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vitistack_operator",
+		Name:      "cache_bytes",
+		Help:      "Approximate memory held by the cached objects of each kind.",
+	}, []string{"kind"})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "vitistack_operator",
+		Name:      "cache_entries",
+		Help:      "Number of objects in the cache index of this replica.",
+	}, cacheEntries)
+)
+
+// cacheEntries returns the number of objects in the operator's cache index, 0 before it is created.
+// It does not list the backend's keys, which on redis would scan the whole server on every scrape.
+func cacheEntries() float64 {
+	if Cache == nil || Cache.index == nil {
+		return 0
+	}
+	return float64(Cache.index.len())
+}
+
+// recordLookup counts a cache lookup as a hit or a miss
+func recordLookup(found bool) {
+	if found {
+		cacheHits.Inc()
+		return
+	}
+	cacheMisses.Inc()
+}
+
+// recordBackendError counts a failed operation of the cache backend
+func recordBackendError(operation string) {
+	cacheBackendErrors.WithLabelValues(operation).Inc()
+}
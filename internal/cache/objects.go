@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Mode is how the cache stores objects
+type Mode string
+
+const (
+	// ModeJSON stores objects as JSON strings, decoded again on every read
+	ModeJSON Mode = "json"
+	// ModeObject stores decoded objects, typed if a converter is registered for their kind,
+	// and hands out copies on read
+	ModeObject Mode = "object"
+)
+
+// ParseMode parses a cache mode setting
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(value); mode {
+	case ModeJSON, ModeObject:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown cache mode %q, expected %s or %s", value, ModeJSON, ModeObject)
+	}
+}
+
+// Option configures a VitistackCache
+type Option func(*VitistackCache)
+
+// WithMode sets how the cache stores objects, ModeJSON by default
+func WithMode(mode Mode) Option {
+	return func(c *VitistackCache) {
+		c.mode = mode
+	}
+}
+
+// Converter converts a watched object into the typed object the cache stores for its kind
+type Converter func(obj *unstructured.Unstructured) (runtime.Object, error)
+
+var (
+	convertersMutex sync.RWMutex
+	converters      = make(map[string]Converter)
+)
+
+// RegisterConverter sets the converter used to store objects of a kind in ModeObject.
+// Objects of kinds without a converter are stored unstructured.
+func RegisterConverter(kind string, converter Converter) {
+	convertersMutex.Lock()
+	defer convertersMutex.Unlock()
+	converters[kind] = converter
+}
+
+func converterFor(kind string) (Converter, bool) {
+	convertersMutex.RLock()
+	defer convertersMutex.RUnlock()
+	converter, ok := converters[kind]
+	return converter, ok
+}
+
+// TypedConverter returns a converter from unstructured objects to T
+func TypedConverter[T any, PT interface {
+	*T
+	runtime.Object
+}]() Converter {
+	return func(obj *unstructured.Unstructured) (runtime.Object, error) {
+		var typed T
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &typed); err != nil {
+			return nil, err
+		}
+		return PT(&typed), nil
+	}
+}
+
+// storedValue returns the value kept in the cache for an object and its approximate size in bytes.
+// In ModeObject Kubernetes objects are converted or copied, so later changes by the caller do not
+// reach the cache; other values and every value in ModeJSON are stored as JSON.
+func (dccache VitistackCache) storedValue(kind string, value any) (any, int, error) {
+	if dccache.mode == ModeObject {
+		switch object := value.(type) {
+		case *unstructured.Unstructured:
+			size := approximateSize(object.Object)
+			if converter, ok := converterFor(kind); ok {
+				typed, err := converter(object)
+				if err != nil {
+					return nil, 0, fmt.Errorf("failed to convert %s %s: %w", kind, object.GetName(), err)
+				}
+				return typed, size, nil
+			}
+			return object.DeepCopy(), size, nil
+		case runtime.Object:
+			encoded, err := json.Marshal(object)
+			if err != nil {
+				return nil, 0, err
+			}
+			return object.DeepCopyObject(), len(encoded), nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return string(encoded), len(encoded), nil
+}
+
+// encodeValue returns the JSON of a cached value
+func encodeValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case runtime.Object:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	default:
+		return "", fmt.Errorf("cached value is a %T, not a string or an object", value)
+	}
+}
+
+// decodeValue returns a cached value as T. The result never shares memory with the cache.
+func decodeValue[T any](value any) (T, error) {
+	var decoded T
+	switch v := value.(type) {
+	case string:
+		err := json.Unmarshal([]byte(v), &decoded)
+		return decoded, err
+	case *T:
+		if object, ok := value.(runtime.Object); ok {
+			if copied, ok := any(object.DeepCopyObject()).(*T); ok {
+				return *copied, nil
+			}
+		}
+	case *unstructured.Unstructured:
+		if _, ok := any(&decoded).(*unstructured.Unstructured); !ok {
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(v.DeepCopy().Object, &decoded)
+			return decoded, err
+		}
+	}
+
+	// Any other stored value, such as a typed object of another kind, round trips through JSON
+	encoded, err := encodeValue(value)
+	if err != nil {
+		return decoded, err
+	}
+	err = json.Unmarshal([]byte(encoded), &decoded)
+	return decoded, err
+}
+
+// getValue returns the value stored under a key and counts the lookup
+func (dccache VitistackCache) getValue(ctx context.Context, key Key) (any, bool, error) {
+	value, ok, err := dccache.layerGet(ctx, key.String())
+	if err != nil {
+		return nil, false, err
+	}
+	found := ok && value != nil
+	recordLookup(found)
+	return value, found, nil
+}
+
+// GetAs returns a copy of the object with the given key as T. The second return value is false
+// if the object is not cached. It returns ErrStale if the object is older than the maximum staleness.
+func GetAs[T any](ctx context.Context, c *VitistackCache, key Key) (T, bool, error) {
+	if err := c.checkFresh(key); err != nil {
+		var zero T
+		return zero, false, err
+	}
+	value, ok, err := c.getValue(ctx, key)
+	if err != nil || !ok {
+		var zero T
+		return zero, false, err
+	}
+	decoded, err := decodeValue[T](value)
+	return decoded, err == nil, err
+}
+
+// GetAsByUID returns a copy of the object with the given UID as T. The second return value is false
+// if the object is not cached.
+func GetAsByUID[T any](ctx context.Context, c *VitistackCache, uid string) (T, bool, error) {
+	key, ok := c.index.keyForUID(uid)
+	if !ok {
+		recordLookup(false)
+		var zero T
+		return zero, false, nil
+	}
+	return GetAs[T](ctx, c, key)
+}
+
+// ListAs returns copies of the cached objects matching the query as T, in key order.
+// It returns ErrStale if any of them is older than the maximum staleness, rather than leave it out.
+func ListAs[T any](ctx context.Context, c *VitistackCache, query Query) ([]T, error) {
+	keys := c.Find(query)
+	objects := make([]T, 0, len(keys))
+	for _, key := range keys {
+		object, ok, err := GetAs[T](ctx, c, key)
+		if errors.Is(err, ErrStale) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cached object %s: %w", key, err)
+		}
+		if ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// approximateSize estimates the memory held by unstructured content: the length of its strings
+// and map keys plus a fixed overhead per value
+func approximateSize(value any) int {
+	const overhead = 16
+	switch v := value.(type) {
+	case map[string]any:
+		size := overhead
+		for key, item := range v {
+			size += len(key) + overhead + approximateSize(item)
+		}
+		return size
+	case []any:
+		size := overhead
+		for _, item := range v {
+			size += approximateSize(item)
+		}
+		return size
+	case string:
+		return len(v) + overhead
+	default:
+		return overhead
+	}
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func init() {
+	RegisterConverter("ConfigMap", TypedConverter[corev1.ConfigMap]())
+}
+
+func newConfigMap(namespace, name, value string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"namespace": namespace, "name": name, "uid": "uid-" + name},
+		"data":       map[string]any{"value": value},
+	}}
+}
+
+func setConfigMap(t testing.TB, c *VitistackCache, obj *unstructured.Unstructured) Key {
+	t.Helper()
+	key := ObjectKey(configMapsGVR, obj.GetNamespace(), obj.GetName())
+	meta := ObjectMeta{UID: string(obj.GetUID()), Kind: obj.GetKind(), Labels: obj.GetLabels()}
+	if err := c.SetObject(context.Background(), key, meta, obj); err != nil {
+		t.Fatalf("SetObject failed: %v", err)
+	}
+	return key
+}
+
+func TestParseMode(t *testing.T) {
+	for _, value := range []string{"json", "object"} {
+		if mode, err := ParseMode(value); err != nil || string(mode) != value {
+			t.Errorf("ParseMode(%q) = %q, %v", value, mode, err)
+		}
+	}
+	if _, err := ParseMode("typed"); err == nil {
+		t.Error("ParseMode of an unknown mode should fail")
+	}
+}
+
+func TestObjectModeStoresTypedObjects(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache(WithMode(ModeObject))
+	key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+
+	stored, _ := c.cacheLayer.Get(ctx, key.String())
+	if _, ok := stored.(*corev1.ConfigMap); !ok {
+		t.Fatalf("stored value is a %T, want a *v1.ConfigMap", stored)
+	}
+
+	configMap, found, err := GetAs[corev1.ConfigMap](ctx, c, key)
+	if err != nil || !found || configMap.Data["value"] != "one" {
+		t.Fatalf("GetAs = %+v, %v, %v", configMap, found, err)
+	}
+
+	// The JSON API still works on decoded objects
+	value, err := c.GetObject(ctx, key)
+	if err != nil || value == "" {
+		t.Errorf("GetObject = %q, %v", value, err)
+	}
+}
+
+func TestObjectModeCopiesOnWriteAndRead(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache(WithMode(ModeObject))
+	obj := newConfigMap("default", "config", "one")
+	key := setConfigMap(t, c, obj)
+
+	// Changing the object after it was cached does not change the cache
+	obj.Object["data"].(map[string]any)["value"] = "changed"
+
+	configMap, _, _ := GetAs[corev1.ConfigMap](ctx, c, key)
+	configMap.Data["value"] = "changed by reader"
+
+	again, _, _ := GetAs[corev1.ConfigMap](ctx, c, key)
+	if again.Data["value"] != "one" {
+		t.Errorf("cached value = %q, want it unchanged", again.Data["value"])
+	}
+}
+
+func TestObjectModeStoresKindsWithoutConverterUnstructured(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache(WithMode(ModeObject))
+	obj := newConfigMap("default", "untyped", "one")
+	obj.SetKind("Unregistered")
+	key := setConfigMap(t, c, obj)
+
+	stored, _ := c.cacheLayer.Get(ctx, key.String())
+	if _, ok := stored.(*unstructured.Unstructured); !ok {
+		t.Fatalf("stored value is a %T, want unstructured", stored)
+	}
+
+	copied, _, _ := GetAs[unstructured.Unstructured](ctx, c, key)
+	copied.Object["data"].(map[string]any)["value"] = "changed"
+
+	typed, _, err := GetAs[corev1.ConfigMap](ctx, c, key)
+	if err != nil || typed.Data["value"] != "one" {
+		t.Errorf("GetAs = %+v, %v, want the unchanged object", typed.Data, err)
+	}
+}
+
+func TestListAsInBothModes(t *testing.T) {
+	for _, mode := range []Mode{ModeJSON, ModeObject} {
+		t.Run(string(mode), func(t *testing.T) {
+			ctx := context.Background()
+			c := NewMockVitistackCache(WithMode(mode))
+			setConfigMap(t, c, newConfigMap("b", "second", "2"))
+			setConfigMap(t, c, newConfigMap("a", "first", "1"))
+
+			configMaps, err := ListAs[corev1.ConfigMap](ctx, c, Query{Kind: "ConfigMap"})
+			if err != nil {
+				t.Fatalf("ListAs failed: %v", err)
+			}
+			if len(configMaps) != 2 || configMaps[0].Name != "first" || configMaps[1].Data["value"] != "2" {
+				t.Errorf("ListAs = %+v", configMaps)
+			}
+
+			configMap, found, err := GetAsByUID[corev1.ConfigMap](ctx, c, "uid-second")
+			if err != nil || !found || configMap.Name != "second" {
+				t.Errorf("GetAsByUID = %+v, %v, %v", configMap, found, err)
+			}
+		})
+	}
+}
+
+func TestMemoryByKind(t *testing.T) {
+	ctx := context.Background()
+	c := NewMockVitistackCache(WithMode(ModeObject))
+	first := setConfigMap(t, c, newConfigMap("default", "config-1", "1"))
+	setConfigMap(t, c, newConfigMap("default", "config-2", "2"))
+
+	total := c.MemoryByKind()["ConfigMap"]
+	if total <= 0 {
+		t.Fatalf("MemoryByKind = %v, want the size of the config maps", c.MemoryByKind())
+	}
+
+	// Replacing an object does not count it twice
+	setConfigMap(t, c, newConfigMap("default", "config-2", "2"))
+	if got := c.MemoryByKind()["ConfigMap"]; got != total {
+		t.Errorf("MemoryByKind after replace = %d, want %d", got, total)
+	}
+
+	_ = c.DeleteObject(ctx, first)
+	if got := c.MemoryByKind()["ConfigMap"]; got != total/2 {
+		t.Errorf("MemoryByKind after delete = %d, want %d", got, total/2)
+	}
+}
+
+func benchmarkListAs(b *testing.B, mode Mode) {
+	ctx := context.Background()
+	c := NewMockVitistackCache(WithMode(mode))
+	for i := range 100 {
+		setConfigMap(b, c, newConfigMap("default", fmt.Sprintf("config-%d", i), "value"))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		configMaps, err := ListAs[corev1.ConfigMap](ctx, c, Query{Kind: "ConfigMap"})
+		if err != nil || len(configMaps) != 100 {
+			b.Fatalf("ListAs = %d, %v", len(configMaps), err)
+		}
+	}
+}
+
+func BenchmarkListAsJSON(b *testing.B) {
+	benchmarkListAs(b, ModeJSON)
+}
+
+func BenchmarkListAsObject(b *testing.B) {
+	benchmarkListAs(b, ModeObject)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorskHelsenett/ror/pkg/helpers/kvcachehelper"
+	"github.com/redis/go-redis/v9"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+)
+
+// redisScanCount is the number of keys asked for per SCAN call when listing keys
+const redisScanCount = 1000
+
+// redisLayer is a cache layer in a Redis-protocol server, shared by every replica that uses it.
+// It stores the same JSON strings as the in-memory layer. Entries never expire.
+type redisLayer struct {
+	client redis.UniversalClient
+	// match limits the listed keys to the ones under the cache's key prefix
+	match string
+}
+
+func newRedisLayer(client redis.UniversalClient, prefix string) *redisLayer {
+	return &redisLayer{client: client, match: prefix + "*"}
+}
+
+func (l *redisLayer) Get(ctx context.Context, key string, _ ...kvcachehelper.CacheGetOptions) (any, bool) {
+	value, ok, err := l.get(ctx, key)
+	if err != nil {
+		vlog.Error("Failed to read from redis cache", err)
+	}
+	return value, ok
+}
+
+func (l *redisLayer) Set(ctx context.Context, key string, value any, _ ...kvcachehelper.CacheSetOptions) {
+	if err := l.set(ctx, key, value); err != nil {
+		vlog.Error("Failed to write to redis cache", err)
+	}
+}
+
+func (l *redisLayer) Keys(ctx context.Context, _ ...kvcachehelper.CacheKeysOptions) ([]string, error) {
+	keys := make([]string, 0)
+	iterator := l.client.Scan(ctx, 0, l.match, redisScanCount).Iterator()
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Val())
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (l *redisLayer) Remove(ctx context.Context, key string, _ ...kvcachehelper.CacheRemoveOptions) bool {
+	removed, err := l.remove(ctx, key)
+	if err != nil {
+		vlog.Error("Failed to remove from redis cache", err)
+	}
+	return removed
+}
+
+func (l *redisLayer) get(ctx context.Context, key string) (any, bool, error) {
+	value, err := l.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (l *redisLayer) set(ctx context.Context, key string, value any) error {
+	return l.client.Set(ctx, key, value, 0).Err()
+}
+
+func (l *redisLayer) remove(ctx context.Context, key string) (bool, error) {
+	removed, err := l.client.Del(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
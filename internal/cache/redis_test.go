@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newRedisTestCache(t *testing.T, server *miniredis.Miniredis, prefix string) *VitistackCache {
+	t.Helper()
+	c, err := VitistackCache{}.NewVitistackCache(
+		WithRedis(RedisOptions{Address: server.Addr()}),
+		WithKeyPrefix(prefix),
+	)
+	if err != nil {
+		t.Fatalf("NewVitistackCache failed: %v", err)
+	}
+	return c
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, value := range []string{"memory", "redis"} {
+		if backend, err := ParseBackend(value); err != nil || string(backend) != value {
+			t.Errorf("ParseBackend(%q) = %q, %v", value, backend, err)
+		}
+	}
+	if _, err := ParseBackend("etcd"); err == nil {
+		t.Error("ParseBackend of an unknown backend should fail")
+	}
+}
+
+func TestRedisBackendStoresPrefixedJSON(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := newRedisTestCache(t, server, "vitistack-operator:")
+
+	key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+
+	// The entry is stored as JSON under the prefixed key, the same as in memory
+	stored, err := server.Get("vitistack-operator:" + key.String())
+	if err != nil {
+		t.Fatalf("entry not found in redis: %v", err)
+	}
+	memory := NewMockVitistackCache()
+	setConfigMap(t, memory, newConfigMap("default", "config", "one"))
+	if fromMemory, _ := memory.GetObject(ctx, key); stored != fromMemory {
+		t.Errorf("redis entry = %s, want the same JSON as the memory backend %s", stored, fromMemory)
+	}
+
+	configMap, found, err := GetAs[corev1.ConfigMap](ctx, c, key)
+	if err != nil || !found || configMap.Data["value"] != "one" {
+		t.Errorf("GetAs = %+v, %v, %v", configMap, found, err)
+	}
+
+	if err := c.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if server.Exists("vitistack-operator:" + key.String()) {
+		t.Error("entry should be removed from redis")
+	}
+}
+
+func TestRedisBackendIsSharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	first := newRedisTestCache(t, server, "vitistack-operator:")
+	second := newRedisTestCache(t, server, "vitistack-operator:")
+	other := newRedisTestCache(t, server, "other-operator:")
+
+	if err := first.Set(ctx, "shared", "value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, _ := second.Get(ctx, "shared"); value != `"value"` {
+		t.Errorf("Get from the second replica = %q, want the first replica's value", value)
+	}
+
+	keys, err := second.Keys(ctx)
+	if err != nil || len(keys) != 1 || keys[0] != "shared" {
+		t.Errorf("Keys = %v, %v, want the unprefixed key", keys, err)
+	}
+	if _, err := other.Keys(ctx); err == nil {
+		t.Error("a cache with another prefix should not see the key")
+	}
+}
+
+func TestRedisBackendRejectsObjectMode(t *testing.T) {
+	server := miniredis.RunT(t)
+	_, err := VitistackCache{}.NewVitistackCache(WithRedis(RedisOptions{Address: server.Addr()}), WithMode(ModeObject))
+	if err == nil {
+		t.Error("the object mode should need the memory backend")
+	}
+}
+
+func TestRedisBackendFailsWhenUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	address := server.Addr()
+	server.Close()
+
+	if _, err := (VitistackCache{}).NewVitistackCache(WithRedis(RedisOptions{Address: address})); err == nil {
+		t.Error("NewVitistackCache should fail when redis is unreachable")
+	}
+}
+
+func TestRedisBackendDeleteIsIdempotentBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	first := newRedisTestCache(t, server, "vitistack-operator:")
+	second := newRedisTestCache(t, server, "vitistack-operator:")
+
+	// Both replicas watch the object and receive its DELETE event
+	configMap := newConfigMap("default", "config", "one")
+	key := setConfigMap(t, first, configMap)
+	setConfigMap(t, second, configMap)
+
+	if err := first.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject on the first replica failed: %v", err)
+	}
+	if err := second.DeleteObject(ctx, key); err != nil {
+		t.Errorf("DeleteObject of an entry another replica removed = %v, want nil", err)
+	}
+	if second.Contains(key) {
+		t.Error("the second replica should no longer index the deleted object")
+	}
+	if server.Exists("vitistack-operator:" + key.String()) {
+		t.Error("entry should be removed from redis")
+	}
+}
+
+func TestRedisBackendReportsErrors(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := newRedisTestCache(t, server, "vitistack-operator:")
+	key := setConfigMap(t, c, newConfigMap("default", "config", "one"))
+
+	server.SetError("LOADING the server is loading its dataset")
+	before := testutil.ToFloat64(cacheBackendErrors.WithLabelValues("get"))
+	misses := testutil.ToFloat64(cacheMisses)
+
+	if _, found, err := GetAs[corev1.ConfigMap](ctx, c, key); err == nil || found {
+		t.Errorf("GetAs during an outage = %v, %v, want an error", found, err)
+	}
+	if got := testutil.ToFloat64(cacheBackendErrors.WithLabelValues("get")); got != before+1 {
+		t.Errorf("backend get errors = %v, want %v", got, before+1)
+	}
+	if got := testutil.ToFloat64(cacheMisses); got != misses {
+		t.Errorf("a failed lookup should not count as a miss, misses went from %v to %v", misses, got)
+	}
+
+	configMap := newConfigMap("default", "other", "two")
+	otherKey := ObjectKey(configMapsGVR, "default", "other")
+	if err := c.SetObject(ctx, otherKey, ObjectMeta{UID: "uid-other", Kind: "ConfigMap"}, configMap); err == nil {
+		t.Error("SetObject during an outage should fail")
+	}
+	if c.Contains(otherKey) {
+		t.Error("an object that could not be written should not be indexed")
+	}
+	if err := c.DeleteObject(ctx, key); err == nil {
+		t.Error("DeleteObject during an outage should fail")
+	}
+}
+
+func TestCacheEntriesCountsTheIndex(t *testing.T) {
+	server := miniredis.RunT(t)
+	previous := Cache
+	Cache = newRedisTestCache(t, server, "vitistack-operator:")
+	defer func() { Cache = previous }()
+
+	setConfigMap(t, Cache, newConfigMap("default", "config", "one"))
+	// Entries another replica wrote are in redis, but not in this replica's index
+	if err := server.Set("vitistack-operator:/v1/configmaps/default/other", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cacheEntries(); got != 1 {
+		t.Errorf("cacheEntries = %v, want 1", got)
+	}
+}
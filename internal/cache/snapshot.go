@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// snapshotVersion is the format version of cache snapshots. Snapshots of other versions are ignored.
+const snapshotVersion = 1
+
+// ErrInvalidSnapshot is returned for snapshot files that can not be loaded
+var ErrInvalidSnapshot = errors.New("invalid cache snapshot")
+
+// snapshot is the gzip compressed JSON document written to the snapshot file
+type snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Checksum is the hex encoded SHA-256 of Entries
+	Checksum string          `json:"checksum"`
+	Entries  json.RawMessage `json:"entries"`
+}
+
+// snapshotEntry is one cached object in a snapshot
+type snapshotEntry struct {
+	Key    string            `json:"key"`
+	UID    string            `json:"uid,omitempty"`
+	Kind   string            `json:"kind,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	SetAt  time.Time         `json:"setAt"`
+	Object json.RawMessage   `json:"object"`
+}
+
+// WriteSnapshot writes the cached objects to a compressed snapshot file and returns the number of
+// objects written. The file is replaced atomically, so a crash never leaves a partial snapshot.
+func (dccache VitistackCache) WriteSnapshot(ctx context.Context, path string) (int, error) {
+	entries := make([]snapshotEntry, 0)
+	for _, objects := range dccache.index.objectsByResource() {
+		for key, object := range objects {
+			value, ok, err := dccache.layerGet(ctx, key.String())
+			if err != nil {
+				return 0, err
+			}
+			if !ok || value == nil {
+				continue
+			}
+			encoded, err := encodeValue(value)
+			if err != nil {
+				return 0, fmt.Errorf("failed to encode %s: %w", key, err)
+			}
+			entries = append(entries, snapshotEntry{
+				Key:    key.String(),
+				UID:    object.UID,
+				Kind:   object.Kind,
+				Labels: object.Labels,
+				SetAt:  object.setAt,
+				Object: json.RawMessage(encoded),
+			})
+		}
+	}
+
+	encodedEntries, err := json.Marshal(entries)
+	if err != nil {
+		return 0, err
+	}
+	checksum := sha256.Sum256(encodedEntries)
+	document := snapshot{
+		Version:   snapshotVersion,
+		CreatedAt: dccache.now().UTC(),
+		Checksum:  hex.EncodeToString(checksum[:]),
+		Entries:   encodedEntries,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = os.Remove(temporary.Name())
+	}()
+
+	writer := gzip.NewWriter(temporary)
+	if err := json.NewEncoder(writer).Encode(document); err != nil {
+		_ = temporary.Close()
+		return 0, err
+	}
+	if err := writer.Close(); err != nil {
+		_ = temporary.Close()
+		return 0, err
+	}
+	if err := temporary.Sync(); err != nil {
+		_ = temporary.Close()
+		return 0, err
+	}
+	if err := temporary.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(temporary.Name(), path); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// readSnapshot reads and verifies a snapshot file
+func readSnapshot(path string) ([]snapshotEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	var document snapshot
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if document.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrInvalidSnapshot, document.Version, snapshotVersion)
+	}
+	checksum := sha256.Sum256(document.Entries)
+	if hex.EncodeToString(checksum[:]) != document.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+
+	var entries []snapshotEntry
+	if err := json.Unmarshal(document.Entries, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	return entries, nil
+}
+
+// LoadSnapshot loads the objects of a snapshot file written by WriteSnapshot and returns the number
+// of objects loaded. Objects already in the cache are kept. Loaded objects keep the time they were
+// last confirmed and are reported as restored until their watcher delivers them again. A missing
+// file loads nothing, and an invalid file returns ErrInvalidSnapshot without loading anything.
+// In ModeObject the converters must be registered first, or the objects are stored unstructured.
+func (dccache VitistackCache) LoadSnapshot(ctx context.Context, path string) (int, error) {
+	entries, err := readSnapshot(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Validate every entry before loading any, so an invalid snapshot changes nothing
+	keys := make([]Key, len(entries))
+	objects := make([]*unstructured.Unstructured, len(entries))
+	for i, entry := range entries {
+		key, err := ParseKey(entry.Key)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		var content map[string]any
+		if err := json.Unmarshal(entry.Object, &content); err != nil {
+			return 0, fmt.Errorf("%w: object %s: %w", ErrInvalidSnapshot, entry.Key, err)
+		}
+		keys[i] = key
+		objects[i] = &unstructured.Unstructured{Object: content}
+	}
+
+	loaded := 0
+	for i, entry := range entries {
+		if _, ok := dccache.index.get(keys[i]); ok {
+			continue
+		}
+		object := indexedObject{
+			ObjectMeta: ObjectMeta{UID: entry.UID, Kind: entry.Kind, Labels: entry.Labels},
+			setAt:      entry.SetAt,
+			restored:   true,
+		}
+		if err := dccache.storeObject(ctx, keys[i], object, objects[i]); err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// DropRestored removes the objects of a resource that were loaded from a snapshot and not delivered
+// again by the resource's watcher. Called once the watcher's initial list has been handled, it
+// removes the objects that were deleted while the operator was not running.
+func (dccache VitistackCache) DropRestored(ctx context.Context, resource schema.GroupVersionResource) int {
+	dropped := 0
+	for _, key := range dccache.index.restoredKeys(resource) {
+		if err := dccache.DeleteObject(ctx, key); err != nil {
+			vlog.Warn("Failed to drop restored cache entry",
+				"key: ", key.String(),
+				"error: ", err.Error())
+			continue
+		}
+		dropped++
+	}
+	return dropped
+}
+
+// RunSnapshots writes a snapshot every interval until the context is cancelled, and once more then.
+// An interval of zero or less disables the periodic snapshots, only the final one is written.
+// It returns after the last snapshot is written.
+func (dccache VitistackCache) RunSnapshots(ctx context.Context, path string, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	} else {
+		vlog.Info("Periodic cache snapshots are disabled, a snapshot is only written on shutdown")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			dccache.writeSnapshotLogged(context.Background(), path)
+			return
+		case <-tick:
+			dccache.writeSnapshotLogged(ctx, path)
+		}
+	}
+}
+
+func (dccache VitistackCache) writeSnapshotLogged(ctx context.Context, path string) {
+	written, err := dccache.WriteSnapshot(ctx, path)
+	if err != nil {
+		vlog.Error("Failed to write cache snapshot", err)
+		return
+	}
+	vlog.Debug(fmt.Sprintf("Wrote cache snapshot path=%s objects=%d", path, written))
+}
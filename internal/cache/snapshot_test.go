@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func writeTestSnapshot(t *testing.T, c *VitistackCache) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "snapshots", "cache.json.gz")
+	if _, err := c.WriteSnapshot(context.Background(), path); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	return path
+}
+
+// rewriteTestSnapshot decodes a snapshot file, lets change modify it and writes it back
+func rewriteTestSnapshot(t *testing.T, path string, change func(document *snapshot)) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document snapshot
+	if err := json.NewDecoder(reader).Decode(&document); err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+
+	change(&document)
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if err := json.NewEncoder(writer).Encode(document); err != nil {
+		t.Fatal(err)
+	}
+	_ = writer.Close()
+	if err := os.WriteFile(path, buffer.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, mode := range []Mode{ModeJSON, ModeObject} {
+		t.Run(string(mode), func(t *testing.T) {
+			ctx := context.Background()
+			clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+			source := newFreshnessTestCache(clock, WithMode(mode))
+			configMap := newConfigMap("default", "config", "one")
+			configMap.SetLabels(map[string]string{"app": "web"})
+			key := setConfigMap(t, source, configMap)
+			setConfigMap(t, source, newConfigMap("other", "second", "two"))
+			path := writeTestSnapshot(t, source)
+
+			clock.now = clock.now.Add(time.Hour)
+			restarted := newFreshnessTestCache(clock, WithMode(mode))
+			loaded, err := restarted.LoadSnapshot(ctx, path)
+			if err != nil || loaded != 2 {
+				t.Fatalf("LoadSnapshot = %d, %v, want 2 objects", loaded, err)
+			}
+
+			restored, found, err := GetAs[corev1.ConfigMap](ctx, restarted, key)
+			if err != nil || !found || restored.Data["value"] != "one" {
+				t.Errorf("GetAs of a restored object = %+v, %v, %v", restored, found, err)
+			}
+			if uidKey, ok := restarted.KeyForUID("uid-config"); !ok || uidKey != key {
+				t.Errorf("KeyForUID of a restored object = %v, %v", uidKey, ok)
+			}
+			if keys := restarted.Find(Query{LabelSelector: labels.SelectorFromSet(labels.Set{"app": "web"})}); len(keys) != 1 || keys[0] != key {
+				t.Errorf("Find by label = %v, want the restored object", keys)
+			}
+
+			// Restored objects keep the age they had when the snapshot was written
+			if staleness, _ := restarted.Staleness(key); staleness != time.Hour {
+				t.Errorf("Staleness of a restored object = %v, want 1h", staleness)
+			}
+		})
+	}
+}
+
+func TestSnapshotRestoredObjectsStayStaleUntilResync(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	source := newFreshnessTestCache(clock)
+	key := setConfigMap(t, source, newConfigMap("default", "config", "one"))
+	path := writeTestSnapshot(t, source)
+
+	// The restarted operator's watcher is synced, but it has not delivered the object yet
+	clock.now = clock.now.Add(time.Hour)
+	confirmation := func(_ schema.GroupVersionResource) (time.Time, bool) {
+		return clock.now, true
+	}
+	restarted := newFreshnessTestCache(clock, WithConfirmation(confirmation), WithMaxStaleness(10*time.Minute))
+	if _, err := restarted.LoadSnapshot(context.Background(), path); err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+	if _, err := restarted.GetObject(context.Background(), key); !errors.Is(err, ErrStale) {
+		t.Errorf("GetObject of a restored object = %v, want ErrStale", err)
+	}
+	if report := restarted.Freshness()[0]; report.Restored != 1 || report.Stale != 1 {
+		t.Errorf("Freshness = %+v, want one restored stale object", report)
+	}
+
+	// The watcher delivers the object again
+	setConfigMap(t, restarted, newConfigMap("default", "config", "one"))
+	if _, err := restarted.GetObject(context.Background(), key); err != nil {
+		t.Errorf("GetObject after resync failed: %v", err)
+	}
+	if report := restarted.Freshness()[0]; report.Restored != 0 || report.Stale != 0 {
+		t.Errorf("Freshness after resync = %+v", report)
+	}
+}
+
+func TestSnapshotKeepsNewerObjects(t *testing.T) {
+	ctx := context.Background()
+	source := NewMockVitistackCache()
+	key := setConfigMap(t, source, newConfigMap("default", "config", "old"))
+	path := writeTestSnapshot(t, source)
+
+	restarted := NewMockVitistackCache()
+	setConfigMap(t, restarted, newConfigMap("default", "config", "new"))
+	if loaded, err := restarted.LoadSnapshot(ctx, path); err != nil || loaded != 0 {
+		t.Errorf("LoadSnapshot = %d, %v, want no objects loaded", loaded, err)
+	}
+	if configMap, _, _ := GetAs[corev1.ConfigMap](ctx, restarted, key); configMap.Data["value"] != "new" {
+		t.Errorf("the watched object was replaced by the snapshot: %+v", configMap)
+	}
+}
+
+func TestDropRestored(t *testing.T) {
+	ctx := context.Background()
+	source := NewMockVitistackCache()
+	kept := setConfigMap(t, source, newConfigMap("default", "kept", "one"))
+	deleted := setConfigMap(t, source, newConfigMap("default", "deleted", "two"))
+	path := writeTestSnapshot(t, source)
+
+	restarted := NewMockVitistackCache()
+	if _, err := restarted.LoadSnapshot(ctx, path); err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+
+	// Only the kept object is delivered by the watcher's initial list
+	setConfigMap(t, restarted, newConfigMap("default", "kept", "one"))
+	if dropped := restarted.DropRestored(ctx, configMapsGVR); dropped != 1 {
+		t.Errorf("DropRestored = %d, want 1", dropped)
+	}
+	if _, found, _ := GetAs[corev1.ConfigMap](ctx, restarted, deleted); found {
+		t.Error("an object deleted while the operator was stopped should be dropped")
+	}
+	if _, ok := restarted.KeyForUID("uid-deleted"); ok {
+		t.Error("the dropped object should be removed from the index")
+	}
+	if _, found, _ := GetAs[corev1.ConfigMap](ctx, restarted, kept); !found {
+		t.Error("a resynced object should be kept")
+	}
+}
+
+func TestLoadSnapshotIgnoresInvalidFiles(t *testing.T) {
+	source := NewMockVitistackCache()
+	setConfigMap(t, source, newConfigMap("default", "config", "one"))
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, path string)
+	}{
+		{"not compressed", func(t *testing.T, path string) {
+			if err := os.WriteFile(path, []byte(`{"version":1}`), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"truncated", func(t *testing.T, path string) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, content[:len(content)/2], 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"checksum mismatch", func(t *testing.T, path string) {
+			rewriteTestSnapshot(t, path, func(document *snapshot) {
+				document.Entries = bytes.Replace(document.Entries, []byte(`one`), []byte(`two`), 1)
+			})
+		}},
+		{"other version", func(t *testing.T, path string) {
+			rewriteTestSnapshot(t, path, func(document *snapshot) {
+				document.Version = snapshotVersion + 1
+			})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestSnapshot(t, source)
+			tt.change(t, path)
+
+			restarted := NewMockVitistackCache()
+			loaded, err := restarted.LoadSnapshot(context.Background(), path)
+			if !errors.Is(err, ErrInvalidSnapshot) || loaded != 0 {
+				t.Errorf("LoadSnapshot = %d, %v, want ErrInvalidSnapshot", loaded, err)
+			}
+			if keys := restarted.Find(Query{}); len(keys) != 0 {
+				t.Errorf("an invalid snapshot loaded %d objects", len(keys))
+			}
+		})
+	}
+}
+
+func TestLoadSnapshotWithoutFile(t *testing.T) {
+	loaded, err := NewMockVitistackCache().LoadSnapshot(context.Background(), filepath.Join(t.TempDir(), "missing.json.gz"))
+	if err != nil || loaded != 0 {
+		t.Errorf("LoadSnapshot of a missing file = %d, %v, want nothing loaded", loaded, err)
+	}
+}
+
+func TestRunSnapshotsWithoutInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		t.Run(interval.String(), func(t *testing.T) {
+			clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+			c := newFreshnessTestCache(clock)
+			setConfigMap(t, c, newConfigMap("default", "config", "one"))
+			path := filepath.Join(t.TempDir(), "cache.json.gz")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.RunSnapshots(ctx, path, interval)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected no periodic snapshot, got %v", err)
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("RunSnapshots did not return after the context was cancelled")
+			}
+
+			restarted := newFreshnessTestCache(clock)
+			if loaded, err := restarted.LoadSnapshot(context.Background(), path); err != nil || loaded != 1 {
+				t.Errorf("LoadSnapshot of the final snapshot = %d, %v, want 1 object", loaded, err)
+			}
+		})
+	}
+}
@@ -2,95 +2,325 @@ package dynamicclienthandler
 
 import (
 	"fmt"
-	"os"
+	"sync"
+	"time"
 
+	"github.com/spf13/viper"
 	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 )
 
 type DynamicClientHandler interface {
-	AddResource(obj any)
-	DeleteResource(obj any)
-	UpdateResource(_ any, obj any)
-	GetSchemas() []schema.GroupVersionResource
+	AddResource(resource schema.GroupVersionResource, obj any)
+	DeleteResource(resource schema.GroupVersionResource, obj any)
+	UpdateResource(resource schema.GroupVersionResource, oldObj any, obj any)
+	// ResourceSynced is called once the initial list of a resource has been delivered to the handler
+	ResourceSynced(resource schema.GroupVersionResource)
+	GetSchemas() []WatchedResource
 }
 
-func Start(discoveryClient *discovery.DiscoveryClient, dynamicClient dynamic.Interface, dynamichandler DynamicClientHandler, stop chan struct{}, sigs chan os.Signal) error {
+// WatcherStatus describes whether a watched resource is available in the cluster and its watcher is running
+type WatcherStatus struct {
+	WatchedResource
+	Active    bool             `json:"active"`
+	State     InformerState    `json:"state"`
+	StartedAt *time.Time       `json:"startedAt,omitempty"`
+	Informers []InformerStatus `json:"informers,omitempty"`
+}
+
+// Start starts a watcher for every schema that is available in the cluster. Discovery is polled
+// every DISCOVERY_POLL_INTERVAL afterwards, so watchers are started for CRDs that are installed later
+// and stopped for CRDs that are removed.
+func Start(discoveryClient *discovery.DiscoveryClient, dynamicClient dynamic.Interface, dynamichandler DynamicClientHandler, stop <-chan struct{}) error {
 	vlog.Info("Starting dynamic watchers")
 
 	schemas := dynamichandler.GetSchemas()
 	vlog.Info(fmt.Sprintf("Found schemas to watch count=%d", len(schemas)))
 
-	for _, schema := range schemas {
-		vlog.Info(fmt.Sprintf(
-			"Checking resource availability group=%s version=%s resource=%s",
-			schema.Group,
-			schema.Version,
-			schema.Resource,
-		))
+	watchersMutex.Lock()
+	watchedSchemas = schemas
+	watchersMutex.Unlock()
+
+	syncWatchers(discoveryClient, dynamicClient, dynamichandler, schemas)
+
+	go func() {
+		<-stop
+		stopWatchers()
+	}()
+
+	interval := viper.GetDuration(consts.DISCOVERY_POLL_INTERVAL)
+	if interval <= 0 {
+		vlog.Info("Discovery polling is disabled, watchers are only started at startup")
+		return nil
+	}
+	go wait.Until(func() {
+		syncWatchers(discoveryClient, dynamicClient, dynamichandler, schemas)
+	}, interval, stop)
+
+	return nil
+}
 
+// syncWatchers starts watchers for schemas that have become available and stops watchers
+// for schemas that are no longer served by the cluster
+func syncWatchers(discoveryClient *discovery.DiscoveryClient, dynamicClient dynamic.Interface, dynamichandler DynamicClientHandler, schemas []WatchedResource) {
+	for _, watched := range schemas {
+		schema := watched.GroupVersionResource()
 		check, err := discovery.IsResourceEnabled(discoveryClient, schema)
 		if err != nil {
+			// Keep running watchers as they are, the API server may just be unavailable
 			vlog.Error("Could not query resources from cluster", err)
+			continue
 		}
-		if check {
+
+		watchersMutex.RLock()
+		_, running := watchers[schema]
+		watchersMutex.RUnlock()
+
+		switch {
+		case check && !running:
 			vlog.Info(fmt.Sprintf("Resource is available, creating watcher resource=%s", schema.Resource))
-			controller := newDynamicWatcher(dynamichandler, dynamicClient, schema)
-			go func(res string) {
-				vlog.Info(fmt.Sprintf("Starting watcher for resource %s", res))
-				controller.Run(stop)
-			}(schema.Resource)
-		} else {
-			errmsg := fmt.Sprintf("Could not register resource %s", schema.Resource)
-			vlog.Info(errmsg)
+			startWatcher(dynamichandler, dynamicClient, watched)
+		case !check && running:
+			vlog.Info(fmt.Sprintf("Resource is no longer available, stopping watcher resource=%s", schema.Resource))
+			stopWatcher(schema)
+		case !check:
+			vlog.Debug(fmt.Sprintf("Resource is not available yet resource=%s", schema.Resource))
 		}
 	}
-	return nil
 }
 
-type DynamicWatcher struct {
-	dynInformer cache.SharedIndexInformer
-	client      dynamic.Interface
-	factory     dynamicinformer.DynamicSharedInformerFactory
+// Started watchers by resource, used to read the informers' current state
+var (
+	watchers       = make(map[schema.GroupVersionResource]*DynamicWatcher)
+	watchedSchemas []WatchedResource
+	watchersMutex  sync.RWMutex
+)
+
+func startWatcher(dynamichandler DynamicClientHandler, dynamicClient dynamic.Interface, resource WatchedResource) {
+	watcher := newDynamicWatcher(dynamichandler, dynamicClient, resource)
+
+	vlog.Info(fmt.Sprintf("Starting watcher for resource %s", resource.Resource))
+	watcher.Start()
+
+	watchersMutex.Lock()
+	watchers[resource.GroupVersionResource()] = watcher
+	watchersMutex.Unlock()
 }
 
-func (c *DynamicWatcher) Run(stop <-chan struct{}) {
-	// Start the informer factory
-	c.factory.Start(stop)
+func stopWatcher(resource schema.GroupVersionResource) {
+	watchersMutex.Lock()
+	watcher, ok := watchers[resource]
+	delete(watchers, resource)
+	watchersMutex.Unlock()
 
-	// Wait for cache to sync before processing events
-	if !cache.WaitForCacheSync(stop, c.dynInformer.HasSynced) {
-		vlog.Error("Failed to sync cache", nil)
-		return
+	if ok {
+		watcher.Stop()
 	}
+}
 
-	vlog.Info("Cache synced successfully, starting to process events")
+func stopWatchers() {
+	watchersMutex.Lock()
+	stopping := watchers
+	watchers = make(map[schema.GroupVersionResource]*DynamicWatcher)
+	watchersMutex.Unlock()
 
-	// Wait for stop signal
-	<-stop
+	for _, watcher := range stopping {
+		watcher.Stop()
+	}
+	stopFactories()
 }
 
-// Function creates a new dynamic controller to listen for api-changes in provided GroupVersionResource
-func newDynamicWatcher(dynamichandler DynamicClientHandler, client dynamic.Interface, resource schema.GroupVersionResource) *DynamicWatcher {
-	dynWatcher := &DynamicWatcher{}
-	dynInformer := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
-	informer := dynInformer.ForResource(resource).Informer()
+// GetWatchers returns the state of the watcher for every watched schema
+func GetWatchers() []WatcherStatus {
+	watchersMutex.RLock()
+	defer watchersMutex.RUnlock()
 
-	dynWatcher.client = client
-	dynWatcher.dynInformer = informer
-	dynWatcher.factory = dynInformer
+	statuses := make([]WatcherStatus, 0, len(watchedSchemas))
+	for _, resource := range watchedSchemas {
+		status := WatcherStatus{WatchedResource: resource, State: InformerStopped}
+		if watcher, ok := watchers[resource.GroupVersionResource()]; ok {
+			startedAt := watcher.startedAt
+			status.Active = true
+			status.StartedAt = &startedAt
+			status.State = watcher.State()
+			for _, informer := range watcher.informers {
+				status.Informers = append(status.Informers, informer.status())
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
 
-	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    dynamichandler.AddResource,
-		UpdateFunc: dynamichandler.UpdateResource,
-		DeleteFunc: dynamichandler.DeleteResource,
-	})
+// HasSynced reports whether watchers have been started and all of them have completed their initial list
+func HasSynced() bool {
+	watchersMutex.RLock()
+	defer watchersMutex.RUnlock()
+
+	if len(watchers) == 0 {
+		return false
+	}
+	for _, watcher := range watchers {
+		if !watcher.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// ListSynced returns every object in the informer store for the resource.
+// The second return value is false if the resource is not watched or its informer has not synced yet,
+// in which case the store does not reflect the cluster and must not be used to rebuild state.
+func ListSynced(resource schema.GroupVersionResource) ([]*unstructured.Unstructured, bool) {
+	lister, ok := GetLister(resource)
+	if !ok {
+		return nil, false
+	}
+
+	objects, err := lister.List(labels.Everything())
 	if err != nil {
-		vlog.Error("Error adding event handler", err)
+		return nil, false
+	}
+	return objects, true
+}
+
+// DynamicWatcher watches one resource, with an informer per watched namespace from the shared
+// informer factories
+type DynamicWatcher struct {
+	resource  WatchedResource
+	informers []*namespaceInformer
+	handler   DynamicClientHandler
+	client    dynamic.Interface
+	stop      chan struct{}
+	stopOnce  sync.Once
+	startedAt time.Time
+	// registrations are the handler's registrations on the informers, synced once the
+	// initial list has been delivered to the handler
+	registrations []handlerRegistration
+	// events serializes delivering informer events and refreshed objects to the handler, so a
+	// refresh can not re-add an object whose delete was delivered while it was fetched
+	events sync.Mutex
+}
+
+// handlerRegistration is the registration of the watcher's handler on one of its informers
+type handlerRegistration struct {
+	informer     *namespaceInformer
+	registration cache.ResourceEventHandlerRegistration
+}
+
+// Start starts the shared factories' informers, and delivers their events to the handler until
+// Stop is called
+func (c *DynamicWatcher) Start() {
+	startFactories()
+
+	go func() {
+		// Wait for cache to sync before processing events. Sync failures are recorded
+		// by the watch error handlers while the informers keep retrying.
+		if !cache.WaitForCacheSync(c.stop, c.HasSynced) {
+			vlog.Info(fmt.Sprintf("Watcher stopped before cache synced resource=%s", c.resource.Resource))
+			return
+		}
+
+		vlog.Info(fmt.Sprintf("Cache synced successfully, starting to process events resource=%s", c.resource.Resource))
+
+		if !cache.WaitForCacheSync(c.stop, c.handlerSynced) {
+			return
+		}
+		c.handler.ResourceSynced(c.resource.GroupVersionResource())
+	}()
+}
+
+// HasSynced reports whether the informers for all watched namespaces have completed their initial list
+func (c *DynamicWatcher) HasSynced() bool {
+	for _, informer := range c.informers {
+		if !informer.Informer().HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// handlerSynced reports whether the initial list of every informer has been delivered to the handler
+func (c *DynamicWatcher) handlerSynced() bool {
+	for _, registration := range c.registrations {
+		if !registration.registration.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// State returns the combined state of the watcher's informers
+func (c *DynamicWatcher) State() InformerState {
+	states := make([]InformerState, 0, len(c.informers))
+	for _, informer := range c.informers {
+		states = append(states, informer.state())
+	}
+	return combinedState(states)
+}
+
+// Stop stops delivering the informers' events to the handler. The informers themselves belong to
+// the shared factories and keep running until the factories are stopped.
+func (c *DynamicWatcher) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		for _, registration := range c.registrations {
+			if err := registration.informer.Informer().RemoveEventHandler(registration.registration); err != nil {
+				vlog.Error("Error removing event handler", err)
+			}
+		}
+		for _, informer := range c.informers {
+			informer.stop()
+		}
+	})
+}
+
+// Function creates a new dynamic controller to listen for api-changes in provided resource,
+// limited to its namespaces and selectors
+func newDynamicWatcher(dynamichandler DynamicClientHandler, client dynamic.Interface, resource WatchedResource) *DynamicWatcher {
+	dynWatcher := &DynamicWatcher{
+		resource:  resource,
+		handler:   dynamichandler,
+		client:    client,
+		stop:      make(chan struct{}),
+		startedAt: time.Now().UTC(),
+	}
+
+	gvr := resource.GroupVersionResource()
+	for _, namespace := range resource.informerNamespaces() {
+		informer := sharedInformer(client, resource, namespace)
+
+		registration, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj any) {
+				dynWatcher.events.Lock()
+				defer dynWatcher.events.Unlock()
+				dynamichandler.AddResource(gvr, obj)
+			},
+			UpdateFunc: func(oldObj, obj any) {
+				dynWatcher.events.Lock()
+				defer dynWatcher.events.Unlock()
+				dynamichandler.UpdateResource(gvr, oldObj, obj)
+			},
+			DeleteFunc: func(obj any) {
+				dynWatcher.events.Lock()
+				defer dynWatcher.events.Unlock()
+				dynamichandler.DeleteResource(gvr, obj)
+			},
+		})
+		if err != nil {
+			vlog.Error("Error adding event handler", err)
+		} else {
+			dynWatcher.registrations = append(dynWatcher.registrations, handlerRegistration{informer: informer, registration: registration})
+		}
+
+		dynWatcher.informers = append(dynWatcher.informers, informer)
 	}
 
 	return dynWatcher
@@ -0,0 +1,67 @@
+package dynamicclienthandler
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+type testHandler struct{}
+
+func (testHandler) AddResource(schema.GroupVersionResource, any)         {}
+func (testHandler) DeleteResource(schema.GroupVersionResource, any)      {}
+func (testHandler) UpdateResource(schema.GroupVersionResource, any, any) {}
+func (testHandler) ResourceSynced(schema.GroupVersionResource)           {}
+func (testHandler) GetSchemas() []WatchedResource                        { return nil }
+
+func TestStartAndStopWatcherUpdatesReportedWatchers(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+	machineClasses := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineclasses"}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource(): "MachineList",
+	})
+
+	watchersMutex.Lock()
+	watchedSchemas = []WatchedResource{machines, machineClasses}
+	watchersMutex.Unlock()
+	defer stopWatchers()
+
+	startWatcher(testHandler{}, client, machines)
+
+	stop := make(chan struct{})
+	time.AfterFunc(5*time.Second, func() { close(stop) })
+	if !cache.WaitForCacheSync(stop, HasSynced) {
+		t.Fatal("watcher did not sync")
+	}
+
+	statuses := GetWatchers()
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 watched schemas, got %d", len(statuses))
+	}
+	if !statuses[0].Active || statuses[0].State != InformerSynced || statuses[0].StartedAt == nil {
+		t.Errorf("expected machines watcher to be active and synced, got %+v", statuses[0])
+	}
+	if statuses[1].Active || statuses[1].State != InformerStopped {
+		t.Errorf("expected machineclasses watcher to be inactive, got %+v", statuses[1])
+	}
+	if confirmedAt, ok := ConfirmedAt(machines.GroupVersionResource()); !ok || time.Since(confirmedAt) > time.Second {
+		t.Errorf("expected a synced watcher to confirm its objects now, got %v, %v", confirmedAt, ok)
+	}
+
+	stopWatcher(machines.GroupVersionResource())
+
+	if statuses := GetWatchers(); statuses[0].Active {
+		t.Errorf("expected machines watcher to be stopped, got %+v", statuses[0])
+	}
+	if _, ok := ListSynced(machines.GroupVersionResource()); ok {
+		t.Error("expected no synced list for a stopped watcher")
+	}
+	if _, ok := ConfirmedAt(machines.GroupVersionResource()); ok {
+		t.Error("expected a stopped watcher not to confirm its objects")
+	}
+}
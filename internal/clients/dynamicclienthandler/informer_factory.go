@@ -0,0 +1,104 @@
+package dynamicclienthandler
+
+import (
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+)
+
+// factoryScope identifies a shared informer factory: the client, the namespace its informers list
+// and the selectors they apply. Watched resources with the same scope share one factory.
+type factoryScope struct {
+	client        dynamic.Interface
+	namespace     string
+	labelSelector string
+	fieldSelector string
+}
+
+// factoryInformer identifies an informer of a shared factory
+type factoryInformer struct {
+	scope    factoryScope
+	resource schema.GroupVersionResource
+}
+
+// The shared informer factories run until stopFactories is called. A factory can not stop or
+// forget a single informer, so the informer of a resource that is no longer watched keeps running
+// until then, and is reused with its store when the resource is watched again.
+var (
+	factories        = make(map[factoryScope]dynamicinformer.DynamicSharedInformerFactory)
+	factoryInformers = make(map[factoryInformer]*namespaceInformer)
+	factoriesStop    = make(chan struct{})
+	factoriesMutex   sync.Mutex
+)
+
+// sharedInformer returns the informer of a watched resource in one namespace from the shared
+// factory of its scope, creating the factory and the informer if needed. The informer is started
+// by startFactories once the caller has added its event handler.
+func sharedInformer(client dynamic.Interface, resource WatchedResource, namespace string) *namespaceInformer {
+	scope := factoryScope{
+		client:        client,
+		namespace:     namespace,
+		labelSelector: resource.LabelSelector,
+		fieldSelector: resource.fieldSelector(),
+	}
+	key := factoryInformer{scope: scope, resource: resource.GroupVersionResource()}
+
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	if informer, ok := factoryInformers[key]; ok {
+		informer.restart()
+		return informer
+	}
+
+	factory, ok := factories[scope]
+	if !ok {
+		factory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, viper.GetDuration(consts.INFORMER_RESYNC_PERIOD), namespace, scope.tweakListOptions)
+		factories[scope] = factory
+	}
+
+	genericInformer := factory.ForResource(key.resource)
+	if err := genericInformer.Informer().AddIndexers(informerIndexers()); err != nil {
+		vlog.Error("Error adding informer indexers", err)
+	}
+	informer := newNamespaceInformer(genericInformer, key.resource, namespace)
+	factoryInformers[key] = informer
+	return informer
+}
+
+// startFactories starts the informers of the shared factories that are not running yet
+func startFactories() {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	for _, factory := range factories {
+		factory.Start(factoriesStop)
+	}
+}
+
+// stopFactories stops every shared factory's informers, waits for them to shut down and forgets
+// the factories, so watchers started afterwards get new ones
+func stopFactories() {
+	factoriesMutex.Lock()
+	stopping := factories
+	close(factoriesStop)
+	factories = make(map[factoryScope]dynamicinformer.DynamicSharedInformerFactory)
+	factoryInformers = make(map[factoryInformer]*namespaceInformer)
+	factoriesStop = make(chan struct{})
+	factoriesMutex.Unlock()
+
+	for _, factory := range stopping {
+		factory.Shutdown()
+	}
+}
+
+// tweakListOptions applies the scope's selectors to the list and watch calls of its informers
+func (s factoryScope) tweakListOptions(options *metav1.ListOptions) {
+	WatchedResource{LabelSelector: s.labelSelector, FieldSelector: s.fieldSelector}.tweakListOptions(options)
+}
@@ -0,0 +1,61 @@
+package dynamicclienthandler
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestWatchersShareFactoriesByScope(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+	machineClasses := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineclasses"}
+	configMaps := WatchedResource{Version: "v1", Resource: "configmaps", Namespaces: []string{"a"}, LabelSelector: "app=vitistack"}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource():       "MachineList",
+		machineClasses.GroupVersionResource(): "MachineClassList",
+		configMaps.GroupVersionResource():     "ConfigMapList",
+	})
+	defer stopWatchers()
+
+	startWatcher(testHandler{}, client, machines)
+	startWatcher(testHandler{}, client, machineClasses)
+	startWatcher(testHandler{}, client, configMaps)
+
+	factoriesMutex.Lock()
+	factoryCount, informerCount := len(factories), len(factoryInformers)
+	factoriesMutex.Unlock()
+	if factoryCount != 2 || informerCount != 3 {
+		t.Errorf("expected 2 factories with 3 informers, got %d factories with %d informers", factoryCount, informerCount)
+	}
+
+	watchersMutex.RLock()
+	informer := watchers[machines.GroupVersionResource()].informers[0]
+	watchersMutex.RUnlock()
+
+	// A watcher started again for the same resource gets the factory's informer back
+	stopWatcher(machines.GroupVersionResource())
+	if state := informer.state(); state != InformerStopped {
+		t.Errorf("expected the informer of a stopped watcher to be reported stopped, got %s", state)
+	}
+	startWatcher(testHandler{}, client, machines)
+
+	watchersMutex.RLock()
+	restarted := watchers[machines.GroupVersionResource()].informers[0]
+	watchersMutex.RUnlock()
+	if restarted != informer {
+		t.Error("expected the restarted watcher to reuse the factory's informer")
+	}
+	if state := restarted.state(); state == InformerStopped {
+		t.Errorf("expected the reused informer to be running, got %s", state)
+	}
+
+	stopWatchers()
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	if len(factories) != 0 || len(factoryInformers) != 0 {
+		t.Errorf("expected the factories to be forgotten after stopping, got %d", len(factories))
+	}
+}
@@ -0,0 +1,259 @@
+package dynamicclienthandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerState is the lifecycle state of an informer
+type InformerState string
+
+const (
+	// InformerStarting is an informer that has not completed its initial list yet
+	InformerStarting InformerState = "starting"
+	// InformerSynced is an informer whose store reflects the cluster
+	InformerSynced InformerState = "synced"
+	// InformerFailing is an informer whose last list or watch failed and has not recovered since
+	InformerFailing InformerState = "failing"
+	// InformerStopped is an informer that is not running
+	InformerStopped InformerState = "stopped"
+)
+
+// Watch error reasons recorded for failing informers
+const (
+	WatchErrorExpired      = "Expired"
+	WatchErrorForbidden    = "Forbidden"
+	WatchErrorUnauthorized = "Unauthorized"
+	WatchErrorNotFound     = "NotFound"
+	WatchErrorOther        = "Error"
+)
+
+// InformerStatus is a point-in-time view of one informer of a watched resource
+type InformerStatus struct {
+	Namespace               string        `json:"namespace,omitempty"`
+	State                   InformerState `json:"state"`
+	LastSyncResourceVersion string        `json:"lastSyncResourceVersion,omitempty"`
+	Failures                int64         `json:"failures"`
+	LastErrorReason         string        `json:"lastErrorReason,omitempty"`
+	LastError               string        `json:"lastError,omitempty"`
+	LastErrorTime           *time.Time    `json:"lastErrorTime,omitempty"`
+}
+
+// namespaceInformer is the informer of a watched resource in one namespace, with the
+// failures reported by its watch error handler
+type namespaceInformer struct {
+	informers.GenericInformer
+	resource  schema.GroupVersionResource
+	namespace string
+
+	mutex         sync.Mutex
+	stopped       bool
+	failures      int64
+	lastError     error
+	lastErrorTime time.Time
+	// failedAtResourceVersion is the last synced resourceVersion when the last error happened.
+	// The informer has recovered once its resourceVersion moves past it.
+	failedAtResourceVersion string
+	// failingSince is when the current run of failures started, the last time the store was known current
+	failingSince time.Time
+}
+
+func newNamespaceInformer(informer informers.GenericInformer, resource schema.GroupVersionResource, namespace string) *namespaceInformer {
+	ni := &namespaceInformer{
+		GenericInformer: informer,
+		resource:        resource,
+		namespace:       namespace,
+	}
+	err := informer.Informer().SetWatchErrorHandlerWithContext(ni.handleWatchError)
+	if err != nil {
+		vlog.Error("Error setting watch error handler", err)
+	}
+	return ni
+}
+
+// handleWatchError records a failed list or watch. The reflector retries with backoff on its own.
+func (ni *namespaceInformer) handleWatchError(_ context.Context, _ *cache.Reflector, err error) {
+	// A closed watch is a normal part of the watch lifecycle, not a failure
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return
+	}
+
+	ni.mutex.Lock()
+	// The informer of a resource that is no longer watched keeps failing until its factory stops
+	if ni.stopped {
+		ni.mutex.Unlock()
+		return
+	}
+	now := time.Now().UTC()
+	if !ni.failingLocked() {
+		ni.failingSince = now
+	}
+	ni.failures++
+	ni.lastError = err
+	ni.lastErrorTime = now
+	ni.failedAtResourceVersion = ni.Informer().LastSyncResourceVersion()
+	failures := ni.failures
+	ni.mutex.Unlock()
+
+	vlog.Warn("Watch failed for resource",
+		"resource: ", ni.resource.String(),
+		"namespace: ", ni.namespace,
+		"reason: ", watchErrorReason(err),
+		"failures: ", failures,
+		"error: ", err.Error())
+}
+
+// state returns the current state of the informer
+func (ni *namespaceInformer) state() InformerState {
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+
+	switch {
+	case ni.stopped:
+		return InformerStopped
+	case ni.failingLocked():
+		return InformerFailing
+	case !ni.Informer().HasSynced():
+		return InformerStarting
+	default:
+		return InformerSynced
+	}
+}
+
+// failingLocked reports whether the last error has not been followed by a successful list or watch.
+// The caller must hold the mutex.
+func (ni *namespaceInformer) failingLocked() bool {
+	return ni.lastError != nil && ni.Informer().LastSyncResourceVersion() == ni.failedAtResourceVersion
+}
+
+// confirmedAt returns the last time the informer's store was known to reflect the cluster:
+// now while it is synced, and when it started failing while it fails after its initial list
+func (ni *namespaceInformer) confirmedAt() (time.Time, bool) {
+	switch ni.state() {
+	case InformerSynced:
+		return time.Now().UTC(), true
+	case InformerFailing:
+		if !ni.Informer().HasSynced() {
+			return time.Time{}, false
+		}
+		ni.mutex.Lock()
+		defer ni.mutex.Unlock()
+		return ni.failingSince, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func (ni *namespaceInformer) stop() {
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+	ni.stopped = true
+}
+
+// restart marks an informer that is watched again as running
+func (ni *namespaceInformer) restart() {
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+	ni.stopped = false
+}
+
+// status returns a point-in-time view of the informer
+func (ni *namespaceInformer) status() InformerStatus {
+	status := InformerStatus{
+		Namespace:               ni.namespace,
+		State:                   ni.state(),
+		LastSyncResourceVersion: ni.Informer().LastSyncResourceVersion(),
+	}
+
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+	status.Failures = ni.failures
+	if ni.lastError != nil {
+		lastErrorTime := ni.lastErrorTime
+		status.LastErrorReason = watchErrorReason(ni.lastError)
+		status.LastError = ni.lastError.Error()
+		status.LastErrorTime = &lastErrorTime
+	}
+	return status
+}
+
+// watchErrorReason classifies a list or watch error
+func watchErrorReason(err error) string {
+	switch {
+	case apierrors.IsResourceExpired(err), apierrors.IsGone(err):
+		return WatchErrorExpired
+	case apierrors.IsForbidden(err):
+		return WatchErrorForbidden
+	case apierrors.IsUnauthorized(err):
+		return WatchErrorUnauthorized
+	case apierrors.IsNotFound(err):
+		return WatchErrorNotFound
+	default:
+		return WatchErrorOther
+	}
+}
+
+// combinedState returns the state of a resource from the states of its informers:
+// stopped or failing if any informer is, then starting until all have synced
+func combinedState(states []InformerState) InformerState {
+	combined := InformerSynced
+	for _, state := range states {
+		switch state {
+		case InformerStopped:
+			return InformerStopped
+		case InformerFailing:
+			combined = InformerFailing
+		case InformerStarting:
+			if combined != InformerFailing {
+				combined = InformerStarting
+			}
+		}
+	}
+	return combined
+}
+
+// ConfirmedAt returns the last time the watcher's stores were all known to reflect the cluster,
+// so every object they held was current at that time. The second return value is false if the
+// resource is not watched or its informers have not completed their initial list.
+func ConfirmedAt(resource schema.GroupVersionResource) (time.Time, bool) {
+	watchersMutex.RLock()
+	watcher, ok := watchers[resource]
+	watchersMutex.RUnlock()
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var oldest time.Time
+	for _, informer := range watcher.informers {
+		confirmedAt, ok := informer.confirmedAt()
+		if !ok {
+			return time.Time{}, false
+		}
+		if oldest.IsZero() || confirmedAt.Before(oldest) {
+			oldest = confirmedAt
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
+
+// GetWatcherState returns the state of the watcher for a resource, stopped if it is not watched
+func GetWatcherState(resource schema.GroupVersionResource) InformerState {
+	watchersMutex.RLock()
+	watcher, ok := watchers[resource]
+	watchersMutex.RUnlock()
+
+	if !ok {
+		return InformerStopped
+	}
+	return watcher.State()
+}
@@ -0,0 +1,74 @@
+package dynamicclienthandler
+
+import (
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatchErrorsMarkInformerFailing(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource(): "MachineList",
+	})
+	client.PrependReactor("list", "machines", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(machines.GroupVersionResource().GroupResource(), "", nil)
+	})
+
+	watchersMutex.Lock()
+	watchedSchemas = []WatchedResource{machines}
+	watchersMutex.Unlock()
+	defer stopWatchers()
+
+	startWatcher(testHandler{}, client, machines)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for GetWatcherState(machines.GroupVersionResource()) != InformerFailing {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected watcher to be failing, got %s", GetWatcherState(machines.GroupVersionResource()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The store never completed its initial list, so its objects were never confirmed
+	if _, ok := ConfirmedAt(machines.GroupVersionResource()); ok {
+		t.Error("a watcher that never synced should not confirm its objects")
+	}
+
+	status := GetWatchers()[0]
+	if len(status.Informers) != 1 {
+		t.Fatalf("expected 1 informer, got %d", len(status.Informers))
+	}
+	informer := status.Informers[0]
+	if informer.Failures == 0 || informer.LastErrorReason != WatchErrorForbidden || informer.LastErrorTime == nil {
+		t.Errorf("expected a recorded Forbidden failure, got %+v", informer)
+	}
+
+	stopWatcher(machines.GroupVersionResource())
+	if state := GetWatcherState(machines.GroupVersionResource()); state != InformerStopped {
+		t.Errorf("expected stopped watcher, got %s", state)
+	}
+}
+
+func TestCombinedState(t *testing.T) {
+	tests := []struct {
+		states   []InformerState
+		expected InformerState
+	}{
+		{[]InformerState{InformerSynced, InformerSynced}, InformerSynced},
+		{[]InformerState{InformerSynced, InformerStarting}, InformerStarting},
+		{[]InformerState{InformerStarting, InformerFailing}, InformerFailing},
+		{[]InformerState{InformerFailing, InformerStopped}, InformerStopped},
+	}
+	for _, tt := range tests {
+		if state := combinedState(tt.states); state != tt.expected {
+			t.Errorf("combinedState(%v) = %s, expected %s", tt.states, state, tt.expected)
+		}
+	}
+}
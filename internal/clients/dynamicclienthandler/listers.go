@@ -0,0 +1,121 @@
+package dynamicclienthandler
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// UIDIndex is the name of the informer index of objects by UID
+const UIDIndex = "uid"
+
+// informerIndexers returns the indexers added to every watched resource's informers, besides the
+// namespace index the shared factories add
+func informerIndexers() cache.Indexers {
+	return cache.Indexers{
+		UIDIndex: uidIndexFunc,
+	}
+}
+
+func uidIndexFunc(obj any) ([]string, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index object by uid: %w", err)
+	}
+	return []string{string(object.GetUID())}, nil
+}
+
+// ResourceLister reads the objects of one watched resource straight from the stores of its
+// informers, through the listers of the shared factories
+type ResourceLister struct {
+	informers []*namespaceInformer
+}
+
+// GetLister returns a lister for a watched resource. The second return value is false if the
+// resource is not watched or its informers have not synced yet, in which case callers should
+// fall back to another source.
+func GetLister(resource schema.GroupVersionResource) (ResourceLister, bool) {
+	watchersMutex.RLock()
+	watcher, ok := watchers[resource]
+	watchersMutex.RUnlock()
+
+	if !ok || !watcher.HasSynced() {
+		return ResourceLister{}, false
+	}
+	return ResourceLister{informers: watcher.informers}, true
+}
+
+// List returns the objects matching the selector in all watched namespaces
+func (l ResourceLister) List(selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	return l.ListNamespace("", selector)
+}
+
+// ListNamespace returns the objects matching the selector in the given namespace, read through the
+// informers' namespace index. An empty namespace lists all watched namespaces.
+func (l ResourceLister) ListNamespace(namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	objects := make([]*unstructured.Unstructured, 0)
+	for _, informer := range l.informers {
+		var items []runtime.Object
+		var err error
+		if namespace == "" {
+			items, err = informer.Lister().List(selector)
+		} else {
+			items, err = informer.Lister().ByNamespace(namespace).List(selector)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if obj, ok := item.(*unstructured.Unstructured); ok {
+				objects = append(objects, obj)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// Get returns the object with the given namespace and name, or nil if it does not exist.
+// Cluster scoped objects have an empty namespace.
+func (l ResourceLister) Get(namespace, name string) (*unstructured.Unstructured, error) {
+	for _, informer := range l.informers {
+		var item runtime.Object
+		var err error
+		if namespace == "" {
+			item, err = informer.Lister().Get(name)
+		} else {
+			item, err = informer.Lister().ByNamespace(namespace).Get(name)
+		}
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if obj, ok := item.(*unstructured.Unstructured); ok {
+			return obj, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByUID returns the object with the given UID, or nil if it does not exist
+func (l ResourceLister) GetByUID(uid string) (*unstructured.Unstructured, error) {
+	for _, informer := range l.informers {
+		items, err := informer.Informer().GetIndexer().ByIndex(UIDIndex, uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if obj, ok := item.(*unstructured.Unstructured); ok {
+				return obj, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,87 @@
+package dynamicclienthandler
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestMachine(namespace, name, uid string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("vitistack.io/v1alpha1")
+	obj.SetKind("Machine")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(uid))
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestResourceListerReadsAllWatchedNamespaces(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines", Namespaces: []string{"a", "b"}}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource(): "MachineList",
+	},
+		newTestMachine("a", "m1", "uid-1", map[string]string{"tier": "prod"}),
+		newTestMachine("b", "m2", "uid-2", nil),
+		newTestMachine("c", "m3", "uid-3", nil),
+	)
+
+	watchersMutex.Lock()
+	watchedSchemas = []WatchedResource{machines}
+	watchersMutex.Unlock()
+	defer stopWatchers()
+
+	if _, ok := GetLister(machines.GroupVersionResource()); ok {
+		t.Fatal("expected no lister before the watcher is started")
+	}
+
+	startWatcher(testHandler{}, client, machines)
+
+	stop := make(chan struct{})
+	time.AfterFunc(5*time.Second, func() { close(stop) })
+	if !cache.WaitForCacheSync(stop, HasSynced) {
+		t.Fatal("watcher did not sync")
+	}
+
+	lister, ok := GetLister(machines.GroupVersionResource())
+	if !ok {
+		t.Fatal("expected a lister for a synced watcher")
+	}
+
+	all, err := lister.List(labels.Everything())
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected the 2 machines in the watched namespaces, got %d, %v", len(all), err)
+	}
+
+	inB, err := lister.ListNamespace("b", labels.Everything())
+	if err != nil || len(inB) != 1 || inB[0].GetName() != "m2" {
+		t.Errorf("expected only m2 in namespace b, got %v, %v", inB, err)
+	}
+	if inC, err := lister.ListNamespace("c", labels.Everything()); err != nil || len(inC) != 0 {
+		t.Errorf("expected no machines in the unwatched namespace c, got %v, %v", inC, err)
+	}
+
+	prod, err := lister.List(labels.SelectorFromSet(labels.Set{"tier": "prod"}))
+	if err != nil || len(prod) != 1 || prod[0].GetName() != "m1" {
+		t.Errorf("expected only m1 to match tier=prod, got %v, %v", prod, err)
+	}
+
+	if obj, err := lister.GetByUID("uid-2"); err != nil || obj == nil || obj.GetName() != "m2" {
+		t.Errorf("expected m2 by uid, got %v, %v", obj, err)
+	}
+	if obj, err := lister.Get("b", "m2"); err != nil || obj == nil || obj.GetUID() != "uid-2" {
+		t.Errorf("expected b/m2 by name, got %v, %v", obj, err)
+	}
+	if obj, err := lister.GetByUID("uid-3"); err != nil || obj != nil {
+		t.Errorf("expected no object outside the watched namespaces, got %v, %v", obj, err)
+	}
+}
@@ -0,0 +1,46 @@
+package dynamicclienthandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrNotWatched is returned for resources without a running watcher
+var ErrNotWatched = errors.New("resource is not watched")
+
+// Refresh fetches an object from the API server and delivers it to the watcher's handler as an
+// update, without waiting for the next watch event or resync. It returns the API server's error,
+// such as NotFound, if the object can not be fetched. An object the watcher has seen deleted while
+// it was fetched is not delivered and NotFound is returned, so a refresh never undoes a delete.
+func Refresh(ctx context.Context, resource schema.GroupVersionResource, namespace, name string) error {
+	watchersMutex.RLock()
+	watcher, ok := watchers[resource]
+	watchersMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotWatched, resource.String())
+	}
+
+	obj, err := watcher.client.Resource(resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	watcher.events.Lock()
+	defer watcher.events.Unlock()
+	if watcher.HasSynced() {
+		current, err := ResourceLister{informers: watcher.informers}.Get(namespace, name)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return apierrors.NewNotFound(resource.GroupResource(), name)
+		}
+	}
+	watcher.handler.UpdateResource(resource, nil, obj)
+	return nil
+}
@@ -0,0 +1,90 @@
+package dynamicclienthandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// updateRecorder records the objects delivered as updates
+type updateRecorder struct {
+	testHandler
+	mutex   sync.Mutex
+	updated []*unstructured.Unstructured
+}
+
+func (h *updateRecorder) UpdateResource(_ schema.GroupVersionResource, _ any, obj any) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.updated = append(h.updated, obj.(*unstructured.Unstructured))
+}
+
+func TestRefreshDeliversObjectFromAPIServer(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource(): "MachineList",
+	}, newTestMachine("a", "m1", "uid-1", nil))
+
+	ctx := context.Background()
+	if err := Refresh(ctx, machines.GroupVersionResource(), "a", "m1"); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("Refresh of an unwatched resource = %v, want ErrNotWatched", err)
+	}
+
+	handler := &updateRecorder{}
+	startWatcher(handler, client, machines)
+	defer stopWatchers()
+
+	if err := Refresh(ctx, machines.GroupVersionResource(), "a", "m1"); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	handler.mutex.Lock()
+	if len(handler.updated) != 1 || handler.updated[0].GetUID() != "uid-1" {
+		t.Errorf("expected the fetched machine to be delivered as an update, got %v", handler.updated)
+	}
+	handler.mutex.Unlock()
+
+	if err := Refresh(ctx, machines.GroupVersionResource(), "a", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("Refresh of a missing object = %v, want NotFound", err)
+	}
+}
+
+func TestRefreshDoesNotUndoDelete(t *testing.T) {
+	machines := WatchedResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+	machine := newTestMachine("a", "m1", "uid-1", nil)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machines.GroupVersionResource(): "MachineList",
+	}, machine)
+
+	handler := &updateRecorder{}
+	startWatcher(handler, client, machines)
+	defer stopWatchers()
+
+	watchersMutex.RLock()
+	watcher := watchers[machines.GroupVersionResource()]
+	watchersMutex.RUnlock()
+	if !cache.WaitForCacheSync(watcher.stop, watcher.HasSynced) {
+		t.Fatal("watcher did not sync")
+	}
+
+	// The watcher has seen the delete, while the API server still returned the object
+	if err := watcher.informers[0].Informer().GetIndexer().Delete(machine); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Refresh(context.Background(), machines.GroupVersionResource(), "a", "m1"); !apierrors.IsNotFound(err) {
+		t.Errorf("Refresh of an object the watcher saw deleted = %v, want NotFound", err)
+	}
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	if len(handler.updated) != 0 {
+		t.Errorf("expected the deleted machine not to be delivered, got %v", handler.updated)
+	}
+}
@@ -0,0 +1,74 @@
+package dynamicclienthandler
+
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WatchedResource is a resource to watch, optionally scoped to a set of namespaces and
+// to the objects matching a label and field selector
+type WatchedResource struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// Namespaces to watch, all namespaces if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Name limits the watch to objects with this name, shorthand for a metadata.name field selector
+	Name          string `json:"name,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// GroupVersionResource returns the GroupVersionResource of the watched resource
+func (r WatchedResource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// Validate checks that the resource is fully specified and that its selectors parse
+func (r WatchedResource) Validate() error {
+	if r.Version == "" || r.Resource == "" {
+		return errors.New("version and resource are required")
+	}
+	if _, err := labels.Parse(r.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector for %s: %w", r.Resource, err)
+	}
+	if _, err := fields.ParseSelector(r.fieldSelector()); err != nil {
+		return fmt.Errorf("invalid field selector for %s: %w", r.Resource, err)
+	}
+	return nil
+}
+
+// informerNamespaces returns the namespaces to create an informer for, where "" is all namespaces
+func (r WatchedResource) informerNamespaces() []string {
+	if len(r.Namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return r.Namespaces
+}
+
+// tweakListOptions applies the resource's selectors to the informer's list and watch calls
+func (r WatchedResource) tweakListOptions(options *metav1.ListOptions) {
+	if r.LabelSelector != "" {
+		options.LabelSelector = r.LabelSelector
+	}
+	if fieldSelector := r.fieldSelector(); fieldSelector != "" {
+		options.FieldSelector = fieldSelector
+	}
+}
+
+// fieldSelector returns the field selector combined with the name selector
+func (r WatchedResource) fieldSelector() string {
+	if r.Name == "" {
+		return r.FieldSelector
+	}
+	nameSelector := fields.OneTermEqualSelector("metadata.name", r.Name).String()
+	if r.FieldSelector == "" {
+		return nameSelector
+	}
+	return r.FieldSelector + "," + nameSelector
+}
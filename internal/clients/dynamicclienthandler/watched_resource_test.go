@@ -0,0 +1,32 @@
+package dynamicclienthandler
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchedResourceTweakListOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource WatchedResource
+		expected string
+	}{
+		{"no selectors", WatchedResource{Version: "v1", Resource: "configmaps"}, ""},
+		{"name only", WatchedResource{Version: "v1", Resource: "configmaps", Name: "vitistack-config"}, "metadata.name=vitistack-config"},
+		{"name and field selector", WatchedResource{Version: "v1", Resource: "configmaps", Name: "vitistack-config", FieldSelector: "metadata.namespace=vitistack"}, "metadata.namespace=vitistack,metadata.name=vitistack-config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.resource.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			options := metav1.ListOptions{}
+			tt.resource.tweakListOptions(&options)
+			if options.FieldSelector != tt.expected {
+				t.Errorf("expected field selector %q, got %q", tt.expected, options.FieldSelector)
+			}
+		})
+	}
+}
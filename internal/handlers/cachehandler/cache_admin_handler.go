@@ -0,0 +1,161 @@
+package cachehandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// StatsResponse is the number of cached objects and the approximate memory they hold
+type StatsResponse struct {
+	Entries int               `json:"entries"`
+	Bytes   int               `json:"bytes"`
+	Kinds   []cache.KindStats `json:"kinds"`
+}
+
+// EntryResponse is a cached object as it is stored in the cache
+type EntryResponse struct {
+	cache.EntryInfo
+	Value json.RawMessage `json:"value"`
+}
+
+// GetStats serves the number of cached objects and their approximate memory per kind
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	response := StatsResponse{Kinds: cache.Cache.Stats()}
+	for _, kind := range response.Kinds {
+		response.Entries += kind.Entries
+		response.Bytes += kind.Bytes
+	}
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, response)
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize cache stats")
+		return
+	}
+}
+
+// GetKeys serves the keys of the cached objects with their kind and namespace.
+// Query parameters: kind, namespace, name and labelSelector.
+func GetKeys(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cacheQuery := cache.Query{
+		Kind:      query.Get("kind"),
+		Namespace: query.Get("namespace"),
+		Name:      query.Get("name"),
+	}
+	if value := query.Get("labelSelector"); value != "" {
+		selector, err := labels.Parse(value)
+		if err != nil {
+			httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid label selector")
+			return
+		}
+		cacheQuery.LabelSelector = selector
+	}
+
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, cache.Cache.Entries(cacheQuery))
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize cache keys")
+		return
+	}
+}
+
+// GetEntry serves the raw content of the cached object given by the key query parameter,
+// whether or not it is stale
+func GetEntry(w http.ResponseWriter, r *http.Request) {
+	key, ok := entryKey(w, r)
+	if !ok {
+		return
+	}
+
+	entry, value, found, err := cache.Cache.Entry(r.Context(), key)
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to read cache entry")
+		return
+	}
+	if !found {
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Cache entry not found")
+		return
+	}
+
+	err = httphelpers.RespondWithJSON(w, http.StatusOK, EntryResponse{EntryInfo: entry, Value: json.RawMessage(value)})
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize cache entry")
+		return
+	}
+}
+
+// EvictEntry removes the cached object given by the key query parameter. The object is cached
+// again by its next watch event or resync.
+func EvictEntry(w http.ResponseWriter, r *http.Request) {
+	key, ok := entryKey(w, r)
+	if !ok {
+		return
+	}
+
+	if !cache.Cache.Contains(key) {
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Cache entry not found")
+		return
+	}
+	if err := cache.Cache.DeleteObject(r.Context(), key); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to evict cache entry")
+		return
+	}
+
+	vlog.Info("Evicted cache entry", "key: ", key.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RefreshEntry fetches the object given by the key query parameter from the API server and caches
+// it again, also when its entry was evicted. An object that no longer exists in the cluster is evicted.
+func RefreshEntry(w http.ResponseWriter, r *http.Request) {
+	key, ok := entryKey(w, r)
+	if !ok {
+		return
+	}
+
+	err := dynamicclienthandler.Refresh(r.Context(), key.GroupVersionResource(), key.Namespace, key.Name)
+	switch {
+	case errors.Is(err, dynamicclienthandler.ErrNotWatched):
+		httphelpers.RespondWithError(w, http.StatusConflict, "Resource of the cache entry is not watched")
+		return
+	case apierrors.IsNotFound(err):
+		if err := cache.Cache.DeleteObject(r.Context(), key); err != nil {
+			httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to evict cache entry")
+			return
+		}
+		vlog.Info("Evicted cache entry of an object that no longer exists", "key: ", key.String())
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Object no longer exists, cache entry evicted")
+		return
+	case err != nil:
+		vlog.Error("Failed to refresh cache entry", err)
+		httphelpers.RespondWithError(w, http.StatusBadGateway, "Failed to fetch object from the API server")
+		return
+	}
+
+	entry, value, found, err := cache.Cache.Entry(r.Context(), key)
+	if err != nil || !found {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to read refreshed cache entry")
+		return
+	}
+	err = httphelpers.RespondWithJSON(w, http.StatusOK, EntryResponse{EntryInfo: entry, Value: json.RawMessage(value)})
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize cache entry")
+		return
+	}
+}
+
+// entryKey parses the key query parameter, responding with an error if it is invalid
+func entryKey(w http.ResponseWriter, r *http.Request) (cache.Key, bool) {
+	key, err := cache.ParseKey(r.URL.Query().Get("key"))
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid cache key")
+		return cache.Key{}, false
+	}
+	return key, true
+}
@@ -0,0 +1,130 @@
+package cachehandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/handlers/cachehandler"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func setupCache(t *testing.T) cache.Key {
+	t.Helper()
+	cache.Cache = cache.NewMockVitistackCache()
+
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"namespace": "default", "name": "config", "uid": "uid-config"},
+		"data":       map[string]any{"value": "one"},
+	}}
+	key := cache.ObjectKey(configMapsGVR, "default", "config")
+	meta := cache.ObjectMeta{UID: "uid-config", Kind: "ConfigMap"}
+	if err := cache.Cache.SetObject(context.Background(), key, meta, obj); err != nil {
+		t.Fatalf("SetObject failed: %v", err)
+	}
+	return key
+}
+
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/admin/cache/stats", cachehandler.GetStats).Methods("GET")
+	r.HandleFunc("/admin/cache/keys", cachehandler.GetKeys).Methods("GET")
+	r.HandleFunc("/admin/cache/entry", cachehandler.GetEntry).Methods("GET")
+	r.HandleFunc("/admin/cache/entry", cachehandler.EvictEntry).Methods("DELETE")
+	r.HandleFunc("/admin/cache/entry/refresh", cachehandler.RefreshEntry).Methods("POST")
+	return r
+}
+
+func serve(r *mux.Router, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestGetKeysAndStats(t *testing.T) {
+	setupCache(t)
+	r := newRouter()
+
+	w := serve(r, http.MethodGet, "/admin/cache/keys?kind=ConfigMap")
+	var entries []cache.EntryInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GetKeys = %d %s", w.Code, w.Body.String())
+	}
+	if len(entries) != 1 || entries[0].Namespace != "default" || entries[0].Kind != "ConfigMap" {
+		t.Errorf("GetKeys = %+v", entries)
+	}
+
+	if w := serve(r, http.MethodGet, "/admin/cache/keys?labelSelector=a%3D%3D%3Db"); w.Code != http.StatusBadRequest {
+		t.Errorf("GetKeys with an invalid label selector = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = serve(r, http.MethodGet, "/admin/cache/stats")
+	var stats cachehandler.StatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GetStats = %d %s", w.Code, w.Body.String())
+	}
+	if stats.Entries != 1 || stats.Bytes <= 0 || len(stats.Kinds) != 1 {
+		t.Errorf("GetStats = %+v", stats)
+	}
+}
+
+func TestGetAndEvictEntry(t *testing.T) {
+	key := setupCache(t)
+	r := newRouter()
+	target := "/admin/cache/entry?key=" + url.QueryEscape(key.String())
+
+	w := serve(r, http.MethodGet, target)
+	var entry struct {
+		Key   string `json:"key"`
+		Value struct {
+			Data map[string]string `json:"data"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GetEntry = %d %s", w.Code, w.Body.String())
+	}
+	if entry.Key != key.String() || entry.Value.Data["value"] != "one" {
+		t.Errorf("GetEntry = %+v", entry)
+	}
+
+	if w := serve(r, http.MethodGet, "/admin/cache/entry?key=invalid"); w.Code != http.StatusBadRequest {
+		t.Errorf("GetEntry with an invalid key = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := serve(r, http.MethodDelete, target); w.Code != http.StatusNoContent {
+		t.Fatalf("EvictEntry = %d %s", w.Code, w.Body.String())
+	}
+	if cache.Cache.Contains(key) {
+		t.Error("the evicted entry should no longer be cached")
+	}
+	if w := serve(r, http.MethodGet, target); w.Code != http.StatusNotFound {
+		t.Errorf("GetEntry of an evicted entry = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(r, http.MethodDelete, target); w.Code != http.StatusNotFound {
+		t.Errorf("EvictEntry of an evicted entry = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRefreshEvictedEntry(t *testing.T) {
+	key := setupCache(t)
+	r := newRouter()
+	if err := cache.Cache.DeleteObject(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	// An evicted entry is still fetched from the API server, which fails here as nothing is watched
+	w := serve(r, http.MethodPost, "/admin/cache/entry/refresh?key="+url.QueryEscape(key.String()))
+	if w.Code != http.StatusConflict {
+		t.Errorf("RefreshEntry of an evicted entry = %d %s, want %d", w.Code, w.Body.String(), http.StatusConflict)
+	}
+}
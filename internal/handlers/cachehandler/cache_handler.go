@@ -0,0 +1,28 @@
+package cachehandler
+
+import (
+	"net/http"
+
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+)
+
+// FreshnessResponse reports how current the cached objects are
+type FreshnessResponse struct {
+	// MaxStalenessSeconds is the age beyond which cached objects are refused, 0 if there is no bound
+	MaxStalenessSeconds float64                   `json:"maxStalenessSeconds"`
+	Resources           []cache.ResourceFreshness `json:"resources"`
+}
+
+// GetFreshness serves the freshness of the cached objects of every resource
+func GetFreshness(w http.ResponseWriter, r *http.Request) {
+	response := FreshnessResponse{
+		MaxStalenessSeconds: cache.Cache.MaxStaleness().Seconds(),
+		Resources:           cache.Cache.Freshness(),
+	}
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, response)
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize cache freshness")
+		return
+	}
+}
@@ -0,0 +1,86 @@
+package deadletterhandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
+)
+
+// RedriveResponse tells how many dead letters were taken for delivery
+type RedriveResponse struct {
+	Redriven int `json:"redriven"`
+}
+
+func GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, eventmanager.EventBus.DeadLetters())
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize dead letters")
+		return
+	}
+}
+
+// RedriveDeadLetters delivers every dead letter to its subscriber again
+func RedriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	redriven := eventmanager.EventBus.RedriveAll()
+	if err := httphelpers.RespondWithJSON(w, http.StatusAccepted, RedriveResponse{Redriven: redriven}); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize response")
+		return
+	}
+}
+
+// RedriveDeadLetter delivers one dead letter to its subscriber again
+func RedriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	err := eventmanager.EventBus.Redrive(id)
+	if errors.Is(err, eventmanager.ErrDeadLetterNotFound) {
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Dead letter not found")
+		return
+	}
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to redrive dead letter")
+		return
+	}
+
+	if err := httphelpers.RespondWithJSON(w, http.StatusAccepted, RedriveResponse{Redriven: 1}); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize response")
+		return
+	}
+}
+
+// DeleteDeadLetter discards a dead letter without delivering it
+func DeleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	err := eventmanager.EventBus.DiscardDeadLetter(id)
+	if errors.Is(err, eventmanager.ErrDeadLetterNotFound) {
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Dead letter not found")
+		return
+	}
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to discard dead letter")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deadLetterID parses the id URL parameter, responding with an error if it is invalid
+func deadLetterID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid dead letter id")
+		return 0, false
+	}
+	return id, true
+}
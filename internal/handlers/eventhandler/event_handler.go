@@ -0,0 +1,97 @@
+package eventhandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
+)
+
+// EventSummary is a recorded resource event without the resource body
+type EventSummary struct {
+	Sequence        uint64                 `json:"sequence"`
+	Timestamp       time.Time              `json:"timestamp"`
+	Type            eventmanager.EventType `json:"type"`
+	Kind            string                 `json:"kind"`
+	Namespace       string                 `json:"namespace,omitempty"`
+	Name            string                 `json:"name"`
+	UID             string                 `json:"uid"`
+	ResourceVersion string                 `json:"resourceVersion"`
+	// Changed are the paths of the fields changed by an UPDATE event
+	Changed []string `json:"changed,omitempty"`
+}
+
+// EventsResponse is the events since the requested sequence number, and the latest sequence
+// number to ask from next time
+type EventsResponse struct {
+	LatestSequence uint64         `json:"latestSequence"`
+	Events         []EventSummary `json:"events"`
+}
+
+// GetEvents returns the recent resource events from the event history.
+// Query parameters: since (sequence number), kind (repeatable) and age (duration, e.g. 10m).
+func GetEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var since uint64
+	if value := query.Get("since"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid since sequence number")
+			return
+		}
+		since = parsed
+	}
+
+	var after time.Time
+	if value := query.Get("age"); value != "" {
+		age, err := time.ParseDuration(value)
+		if err != nil || age < 0 {
+			httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid age duration")
+			return
+		}
+		after = time.Now().UTC().Add(-age)
+	}
+
+	response := EventsResponse{
+		LatestSequence: eventmanager.EventBus.LatestSequence(),
+		Events:         make([]EventSummary, 0),
+	}
+	for _, event := range eventmanager.EventBus.EventsSince(since, query["kind"]...) {
+		if event.Timestamp.Before(after) {
+			continue
+		}
+		changes := event.Changes()
+		changed := make([]string, 0, len(changes))
+		for _, change := range changes {
+			changed = append(changed, change.Path)
+		}
+		response.Events = append(response.Events, EventSummary{
+			Sequence:        event.Sequence,
+			Timestamp:       event.Timestamp,
+			Type:            event.Type,
+			Kind:            event.Resource.GetKind(),
+			Namespace:       event.Resource.GetNamespace(),
+			Name:            event.Resource.GetName(),
+			UID:             string(event.Resource.GetUID()),
+			ResourceVersion: event.Resource.GetResourceVersion(),
+			Changed:         changed,
+		})
+	}
+
+	if err := httphelpers.RespondWithJSON(w, http.StatusOK, response); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize events")
+		return
+	}
+}
+
+// GetSubscriptions returns the event bus subscriptions and the state of their queues
+func GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, eventmanager.EventBus.SubscriptionStats())
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize subscriptions")
+		return
+	}
+}
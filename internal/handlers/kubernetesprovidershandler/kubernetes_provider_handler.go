@@ -1,9 +1,11 @@
 package kubernetesprovidershandler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/vitistack/vitistack-operator/internal/cache"
 	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
 	"github.com/vitistack/vitistack-operator/internal/helpers/uuidhelpers"
 	"github.com/vitistack/vitistack-operator/internal/repositories"
@@ -26,6 +28,10 @@ func GetKubernetesProviderByUID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	kp, err := repositories.KubernetesProviderRepository.GetByUID(r.Context(), id)
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached Kubernetes providers are stale")
+		return
+	}
 	if err != nil {
 		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve Kubernetes provider")
 		return
@@ -42,6 +48,10 @@ func GetKubernetesProviderByUID(w http.ResponseWriter, r *http.Request) {
 
 func GetKubernetesProviders(w http.ResponseWriter, r *http.Request) {
 	kps, err := repositories.KubernetesProviderRepository.GetAll(r.Context())
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached Kubernetes providers are stale")
+		return
+	}
 	if err != nil {
 		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve Kubernetes providers")
 		return
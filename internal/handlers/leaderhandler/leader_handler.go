@@ -0,0 +1,16 @@
+package leaderhandler
+
+import (
+	"net/http"
+
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	"github.com/vitistack/vitistack-operator/internal/services/leaderelectionservice"
+)
+
+func GetLeader(w http.ResponseWriter, r *http.Request) {
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, leaderelectionservice.GetLeader())
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize leader information")
+		return
+	}
+}
@@ -1,9 +1,11 @@
 package machineprovidershandler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/vitistack/vitistack-operator/internal/cache"
 	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
 	"github.com/vitistack/vitistack-operator/internal/helpers/uuidhelpers"
 	"github.com/vitistack/vitistack-operator/internal/repositories"
@@ -11,6 +13,10 @@ import (
 
 func GetMachineProviders(w http.ResponseWriter, r *http.Request) {
 	machineProviders, err := repositories.MachineProviderRepository.GetAll(r.Context())
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached machine providers are stale")
+		return
+	}
 	if err != nil {
 		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve machine providers")
 		return
@@ -39,6 +45,10 @@ func GetMachineProviderByUID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	machineProvider, err := repositories.MachineProviderRepository.GetByUID(r.Context(), id)
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached machine providers are stale")
+		return
+	}
 	if err != nil {
 		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve machine provider")
 		return
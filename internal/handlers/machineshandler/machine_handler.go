@@ -0,0 +1,103 @@
+package machineshandler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/vitistack/common/pkg/v1alpha1"
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	"github.com/vitistack/vitistack-operator/internal/helpers/uuidhelpers"
+	"github.com/vitistack/vitistack-operator/internal/repositories"
+	"github.com/vitistack/vitistack-operator/internal/repositories/machinerepository"
+)
+
+// machinePhases are the phases a machine can be filtered by
+var machinePhases = []string{
+	v1alpha1.MachinePhasePending,
+	v1alpha1.MachinePhaseCreating,
+	v1alpha1.MachinePhaseRunning,
+	v1alpha1.MachinePhaseStopping,
+	v1alpha1.MachinePhaseStopped,
+	v1alpha1.MachinePhaseTerminating,
+	v1alpha1.MachinePhaseTerminated,
+	v1alpha1.MachinePhaseFailed,
+}
+
+// GetMachines returns the machines matching the query parameters namespace, phase, provider
+// and cluster (the name or ID of the owning cluster)
+func GetMachines(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := machinerepository.MachineFilter{
+		Namespace: query.Get("namespace"),
+		Phase:     query.Get("phase"),
+		Provider:  query.Get("provider"),
+		Cluster:   query.Get("cluster"),
+	}
+	if filter.Phase != "" && !slices.ContainsFunc(machinePhases, func(phase string) bool {
+		return strings.EqualFold(phase, filter.Phase)
+	}) {
+		httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid machine phase")
+		return
+	}
+
+	machines, err := repositories.MachineRepository.Find(r.Context(), filter)
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached machines are stale")
+		return
+	}
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve machines")
+		return
+	}
+
+	if err := httphelpers.RespondWithJSON(w, http.StatusOK, machines); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize machines")
+		return
+	}
+}
+
+func GetMachineByUID(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["uid"]
+
+	// Validate the UUID format
+	if !uuidhelpers.IsValidUUID(id) {
+		httphelpers.RespondWithError(w, http.StatusBadRequest, "Invalid UUID format")
+		return
+	}
+
+	machine, err := repositories.MachineRepository.GetByUID(r.Context(), id)
+	respondWithMachine(w, machine, err)
+}
+
+// GetMachineByNamespacedName returns the machine with the namespace and name in the URL
+func GetMachineByNamespacedName(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	machine, err := repositories.MachineRepository.GetByNamespacedName(r.Context(), vars["namespace"], vars["name"])
+	respondWithMachine(w, machine, err)
+}
+
+// respondWithMachine responds with a machine looked up in the repository
+func respondWithMachine(w http.ResponseWriter, machine v1alpha1.Machine, err error) {
+	if errors.Is(err, cache.ErrStale) {
+		httphelpers.RespondWithError(w, http.StatusServiceUnavailable, "Cached machines are stale")
+		return
+	}
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve machine")
+		return
+	}
+
+	if machine.Name == "" {
+		httphelpers.RespondWithError(w, http.StatusNotFound, "Machine not found")
+		return
+	}
+
+	if err := httphelpers.RespondWithJSON(w, http.StatusOK, machine); err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize machine")
+		return
+	}
+}
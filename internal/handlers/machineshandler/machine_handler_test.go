@@ -0,0 +1,123 @@
+package machineshandler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vitistack/common/pkg/v1alpha1"
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/handlers/machineshandler"
+	"github.com/vitistack/vitistack-operator/internal/repositories"
+	"github.com/vitistack/vitistack-operator/internal/repositories/machinerepository"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const validUUID = "fae23983-e44d-4e29-bf2b-710b79b26534"
+
+// MockMachineRepository is a mock implementation of the MachineRepository interface
+type MockMachineRepository struct {
+	machine v1alpha1.Machine
+	err     error
+	// filter is the last filter passed to Find
+	filter machinerepository.MachineFilter
+}
+
+func (m *MockMachineRepository) GetByUID(ctx context.Context, uid string) (v1alpha1.Machine, error) {
+	if string(m.machine.UID) != uid {
+		return v1alpha1.Machine{}, m.err
+	}
+	return m.machine, m.err
+}
+
+func (m *MockMachineRepository) GetAll(ctx context.Context) ([]v1alpha1.Machine, error) {
+	return []v1alpha1.Machine{m.machine}, m.err
+}
+
+func (m *MockMachineRepository) GetByName(ctx context.Context, name string) (v1alpha1.Machine, error) {
+	return m.machine, m.err
+}
+
+func (m *MockMachineRepository) GetByNamespacedName(ctx context.Context, namespace, name string) (v1alpha1.Machine, error) {
+	if m.machine.Namespace != namespace || m.machine.Name != name {
+		return v1alpha1.Machine{}, m.err
+	}
+	return m.machine, m.err
+}
+
+func (m *MockMachineRepository) Find(ctx context.Context, filter machinerepository.MachineFilter) ([]v1alpha1.Machine, error) {
+	m.filter = filter
+	return []v1alpha1.Machine{m.machine}, m.err
+}
+
+func setupRepository() (*MockMachineRepository, *mux.Router) {
+	repository := &MockMachineRepository{machine: v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "test-machine", UID: types.UID(validUUID)},
+	}}
+	repositories.MachineRepository = repository
+
+	r := mux.NewRouter()
+	r.HandleFunc("/machines", machineshandler.GetMachines)
+	r.HandleFunc("/machines/{uid}", machineshandler.GetMachineByUID)
+	r.HandleFunc("/machines/{namespace}/{name}", machineshandler.GetMachineByNamespacedName)
+	return repository, r
+}
+
+func serve(r *mux.Router, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestGetMachines(t *testing.T) {
+	repository, r := setupRepository()
+
+	w := serve(r, "/machines?phase=running&provider=kubevirt&cluster=cluster-a&namespace=team-a")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "test-machine") {
+		t.Fatalf("GetMachines = %d %s", w.Code, w.Body.String())
+	}
+	want := machinerepository.MachineFilter{Namespace: "team-a", Phase: "running", Provider: "kubevirt", Cluster: "cluster-a"}
+	if repository.filter != want {
+		t.Errorf("filter = %+v, want %+v", repository.filter, want)
+	}
+
+	if w := serve(r, "/machines?phase=sleeping"); w.Code != http.StatusBadRequest {
+		t.Errorf("GetMachines with an unknown phase = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	repository.err = cache.ErrStale
+	if w := serve(r, "/machines"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GetMachines with stale machines = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestGetMachine(t *testing.T) {
+	_, r := setupRepository()
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"by UID", "/machines/" + validUUID, http.StatusOK},
+		{"invalid UID", "/machines/invalid", http.StatusBadRequest},
+		{"missing UID", "/machines/3b0b3d6e-7c1a-4a0e-9a59-8c7e3c8d9f10", http.StatusNotFound},
+		{"by namespace and name", "/machines/team-a/test-machine", http.StatusOK},
+		{"missing name", "/machines/team-a/other-machine", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.target)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.status)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), "test-machine") {
+				t.Errorf("body = %s, want the machine", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package watcherhandler
+
+import (
+	"net/http"
+
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+)
+
+func GetWatchers(w http.ResponseWriter, r *http.Request) {
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, dynamicclienthandler.GetWatchers())
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize watchers")
+		return
+	}
+}
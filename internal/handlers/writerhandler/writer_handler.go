@@ -0,0 +1,16 @@
+package writerhandler
+
+import (
+	"net/http"
+
+	"github.com/vitistack/vitistack-operator/internal/helpers/httphelpers"
+	"github.com/vitistack/vitistack-operator/internal/listeners/resourcewriterlistener"
+)
+
+func GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	err := httphelpers.RespondWithJSON(w, http.StatusOK, resourcewriterlistener.GetQueueStats())
+	if err != nil {
+		httphelpers.RespondWithError(w, http.StatusInternalServerError, "Failed to serialize write queue stats")
+		return
+	}
+}
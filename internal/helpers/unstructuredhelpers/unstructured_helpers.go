@@ -0,0 +1,30 @@
+package unstructuredhelpers
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Convert converts an unstructured object from an informer store to its typed representation
+func Convert[T any](obj *unstructured.Unstructured) (T, error) {
+	var typed T
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &typed)
+	return typed, err
+}
+
+// ConvertAll converts unstructured objects to their typed representation, skipping objects
+// that are not of the given kind
+func ConvertAll[T any](objects []*unstructured.Unstructured, kind string) ([]T, error) {
+	typed := make([]T, 0, len(objects))
+	for _, obj := range objects {
+		if obj.GetKind() != kind {
+			continue
+		}
+		item, err := Convert[T](obj)
+		if err != nil {
+			return nil, err
+		}
+		typed = append(typed, item)
+	}
+	return typed, nil
+}
@@ -1,8 +1,6 @@
 package resourcewriterlistener
 
 import (
-	"sync"
-
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -20,8 +18,16 @@ var machineGVR = schema.GroupVersionResource{
 	Resource: "machines",
 }
 
-// Package-level mutex for vitistack operations to prevent race conditions
-var vitistackRWMutex = &sync.RWMutex{}
+// GroupVersionResources the status is rebuilt from
+var (
+	kubernetesProviderGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "kubernetesproviders"}
+	machineProviderGVR    = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineproviders"}
+	machineClassGVR       = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineclasses"}
+	kubernetesClusterGVR  = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "kubernetesclusters"}
+)
+
+// statusFieldManager is the field manager recorded for all Vitistack status patches
+const statusFieldManager = "vitistack-operator"
 
 // Provider type constants to determine which provider list to update in status
 const (
@@ -43,6 +49,19 @@ func getProviderCountField(providerType string) string {
 	}
 }
 
+// Status fields owned by each writer
+var (
+	clusterStatusFields      = []string{ClusterType, "activeClusters"}
+	machineClassStatusFields = []string{MachineClassType}
+	machineStatusFields      = []string{"activeMachines"}
+	configMapStatusFields    = []string{"displayName", "region", "zone", "location", "description", "infrastructure"}
+)
+
+// providerStatusFields returns the status fields owned by the writer for a provider type
+func providerStatusFields(providerType string) []string {
+	return []string{providerType, getProviderCountField(providerType)}
+}
+
 // initializeStatusDefaults ensures all required status fields have default values
 func initializeStatusDefaults(status map[string]any) {
 	// Initialize empty lists if not present
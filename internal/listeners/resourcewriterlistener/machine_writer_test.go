@@ -0,0 +1,33 @@
+package resourcewriterlistener
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vitistack/vitistack-operator/internal/cache"
+)
+
+func TestUpdateMachineCountCountsCachedMachinesBeforeSync(t *testing.T) {
+	previousCache, previousWriter := cache.Cache, statusWriter
+	defer func() { cache.Cache, statusWriter = previousCache, previousWriter }()
+	cache.Cache = cache.NewMockVitistackCache()
+	statusWriter = newStatusAggregator(time.Second, time.Millisecond, time.Second)
+
+	for _, name := range []string{"m1", "m2"} {
+		key := cache.ObjectKey(machineGVR, "default", name)
+		if err := cache.Cache.SetObject(context.Background(), key, cache.ObjectMeta{UID: name, Kind: "Machine"}, map[string]any{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The machines are not watched here, so they are counted in the cache index
+	if err := updateMachineCount(); err != nil {
+		t.Fatalf("updateMachineCount failed: %v", err)
+	}
+	status := map[string]any{"activeMachines": int64(5)}
+	statusWriter.pending["activeMachines"].apply(status)
+	if status["activeMachines"] != int64(2) {
+		t.Errorf("expected activeMachines 2, got %v", status["activeMachines"])
+	}
+}
@@ -1,213 +1,106 @@
 package resourcewriterlistener
 
 import (
-	"context"
 	"slices"
 
-	"github.com/spf13/viper"
-	"github.com/vitistack/common/pkg/clients/k8sclient"
 	"github.com/vitistack/common/pkg/loggers/vlog"
-	"github.com/vitistack/vitistack-operator/pkg/consts"
 	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // handleMachineClassEvents processes events for MachineClass resources
-func handleMachineClassEvents(event eventmanager.ResourceEvent) {
+func handleMachineClassEvents(event eventmanager.ResourceEvent) error {
 	if event.Resource == nil {
 		vlog.Error("Resource is nil in MachineClass event", nil)
-		return
+		return nil
 	}
-	updateVitistackStatusWithMachineClass(event)
+	return updateVitistackStatusWithMachineClass(event)
 }
 
-// updateVitistackStatusWithMachineClass handles updating the Viti stack CRD status with machine class information
-func updateVitistackStatusWithMachineClass(event eventmanager.ResourceEvent) {
-	// Use the shared dynamic client
-	if k8sclient.DynamicClient == nil {
-		vlog.Error("Dynamic client is not initialized", nil)
-		return
-	}
-
+// updateVitistackStatusWithMachineClass submits a status delta for the machine class to the status aggregator
+func updateVitistackStatusWithMachineClass(event eventmanager.ResourceEvent) error {
 	machineClassName := event.Resource.GetName()
+	key := MachineClassType + "/" + machineClassName
 
 	vlog.Info("Processing MachineClass event",
 		"type: ", string(event.Type),
 		"name: ", machineClassName)
 
-	// Get or create the vitistack CRD
-	vitistackCrdName := viper.GetString(consts.VITISTACKCRDNAME)
-	vitistackObj, err := getOrCreateVitistackCrd(vitistackCrdName)
-	if err != nil {
-		vlog.Error("Failed to get or create Viti stack CRD", err,
-			"name: ", vitistackCrdName)
-		return
-	}
-
 	// Handle based on event type
 	switch event.Type {
 	case eventmanager.EventAdd, eventmanager.EventUpdate:
-		addMachineClassToVitistackStatus(vitistackObj, machineClassName)
+		statusWriter.submit(key, addMachineClassToVitistackStatus(machineClassName))
 	case eventmanager.EventDelete:
-		removeMachineClassFromVitistackStatus(vitistackObj, machineClassName)
+		statusWriter.submit(key, removeMachineClassFromVitistackStatus(machineClassName))
 	default:
 		vlog.Info("Unhandled event type", "type: ", string(event.Type))
 	}
+	return nil
 }
 
-// addMachineClassToVitistackStatus adds a machine class to the status if it doesn't already exist
-func addMachineClassToVitistackStatus(vitistackObj *unstructured.Unstructured, machineClassName string) {
-	vitistackName := vitistackObj.GetName()
-
-	// Acquire write lock for the update operation
-	vitistackRWMutex.Lock()
-	defer vitistackRWMutex.Unlock()
-
-	// Get the latest version
-	latestObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.TODO(), vitistackName, metav1.GetOptions{})
-	if err != nil {
-		vlog.Error("Failed to get Vitistack CRD", err,
-			"name: ", vitistackName)
-		return
-	}
-
-	// Get current machine classes from status (as array of strings)
-	machineClasses, found, err := unstructured.NestedStringSlice(latestObj.Object, "status", "machineClasses")
-	if err != nil {
-		vlog.Error("Failed to get machineClasses from vitistack status", err)
-		return
-	}
-
-	if !found {
-		machineClasses = []string{}
-	}
-
-	// Check if machine class already exists
-	if slices.Contains(machineClasses, machineClassName) {
-		vlog.Info("MachineClass already exists in Viti stack status, no update needed",
-			"machineClass: ", machineClassName,
-			"vitistack: ", vitistackName)
-		return
+// machineClassNames returns the machine class names stored in the status
+func machineClassNames(status map[string]any) []string {
+	machineClasses := make([]string, 0)
+	for _, mc := range statusList(status, "machineClasses") {
+		if name, ok := mc.(string); ok {
+			machineClasses = append(machineClasses, name)
+		}
 	}
+	return machineClasses
+}
 
-	// Add the machine class
-	machineClasses = append(machineClasses, machineClassName)
-
+// setMachineClassNames stores the machine class names in the status
+func setMachineClassNames(status map[string]any, machineClasses []string) {
 	// Convert []string to []interface{} for SetNestedField compatibility
 	machineClassesInterface := make([]any, len(machineClasses))
 	for i, mc := range machineClasses {
 		machineClassesInterface[i] = mc
 	}
-
-	// Ensure status exists
-	status, _, _ := unstructured.NestedMap(latestObj.Object, "status")
-	if status == nil {
-		status = map[string]any{}
-	}
-
-	// Update machineClasses in status
 	status["machineClasses"] = machineClassesInterface
-
-	// Set the updated status
-	err = unstructured.SetNestedField(latestObj.Object, status, "status")
-	if err != nil {
-		vlog.Error("Failed to set status in vitistack", err)
-		return
-	}
-
-	// Update the vitistack resource status
-	_, err = k8sclient.DynamicClient.Resource(vitistackGVR).UpdateStatus(context.TODO(), latestObj, metav1.UpdateOptions{})
-	if err != nil {
-		vlog.Error("Failed to update Viti stack CRD status", err,
-			"name: ", vitistackName)
-		return
-	}
-
-	vlog.Info("Added MachineClass to Viti stack status",
-		"name: ", vitistackName,
-		"machineClass: ", machineClassName)
 }
 
-// removeMachineClassFromVitistackStatus removes a machine class from the status
-func removeMachineClassFromVitistackStatus(vitistackObj *unstructured.Unstructured, machineClassName string) {
-	vitistackName := vitistackObj.GetName()
+// addMachineClassToVitistackStatus returns a delta that adds a machine class to the status if it doesn't already exist
+func addMachineClassToVitistackStatus(machineClassName string) statusDelta {
+	return statusDelta{
+		fields: machineClassStatusFields,
+		apply: func(status map[string]any) bool {
+			machineClasses := machineClassNames(status)
 
-	// Acquire write lock for the update operation
-	vitistackRWMutex.Lock()
-	defer vitistackRWMutex.Unlock()
+			// Check if machine class already exists
+			if slices.Contains(machineClasses, machineClassName) {
+				return false
+			}
 
-	// Get the latest version
-	latestObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.TODO(), vitistackName, metav1.GetOptions{})
-	if err != nil {
-		vlog.Error("Failed to get Viti stack CRD", err,
-			"name: ", vitistackName)
-		return
-	}
+			// Add the machine class
+			setMachineClassNames(status, append(machineClasses, machineClassName))
 
-	// Get current machine classes from status
-	machineClasses, found, err := unstructured.NestedStringSlice(latestObj.Object, "status", "machineClasses")
-	if err != nil {
-		vlog.Error("Failed to get machineClasses from vitistack status", err)
-		return
-	}
-
-	if !found || len(machineClasses) == 0 {
-		vlog.Info("No machineClasses found in vitistack status to remove",
-			"vitistack: ", vitistackName)
-		return
-	}
-
-	// Find and remove the machine class
-	machineClassIndex := -1
-	for i, mc := range machineClasses {
-		if mc == machineClassName {
-			machineClassIndex = i
-			break
-		}
-	}
-
-	if machineClassIndex < 0 {
-		vlog.Info("MachineClass not found in Viti stack status, no removal needed",
-			"machineClass: ", machineClassName,
-			"vitistack: ", vitistackName)
-		return
-	}
-
-	// Remove the machine class
-	machineClasses = slices.Delete(machineClasses, machineClassIndex, machineClassIndex+1)
-
-	// Convert []string to []interface{} for SetNestedField compatibility
-	machineClassesInterface := make([]any, len(machineClasses))
-	for i, mc := range machineClasses {
-		machineClassesInterface[i] = mc
-	}
-
-	// Ensure status exists
-	status, _, _ := unstructured.NestedMap(latestObj.Object, "status")
-	if status == nil {
-		status = map[string]any{}
-	}
-
-	// Update machineClasses in status
-	status["machineClasses"] = machineClassesInterface
-
-	// Set the updated status
-	err = unstructured.SetNestedField(latestObj.Object, status, "status")
-	if err != nil {
-		vlog.Error("Failed to set status in vitistack", err)
-		return
+			vlog.Info("Added MachineClass to Viti stack status",
+				"machineClass: ", machineClassName)
+			return true
+		},
 	}
+}
 
-	// Update the vitistack resource status
-	_, err = k8sclient.DynamicClient.Resource(vitistackGVR).UpdateStatus(context.TODO(), latestObj, metav1.UpdateOptions{})
-	if err != nil {
-		vlog.Error("Failed to update Viti stack CRD status after removal", err,
-			"name: ", vitistackName)
-		return
+// removeMachineClassFromVitistackStatus returns a delta that removes a machine class from the status
+func removeMachineClassFromVitistackStatus(machineClassName string) statusDelta {
+	return statusDelta{
+		fields: machineClassStatusFields,
+		apply: func(status map[string]any) bool {
+			machineClasses := machineClassNames(status)
+
+			// Find and remove the machine class
+			machineClassIndex := slices.Index(machineClasses, machineClassName)
+			if machineClassIndex < 0 {
+				vlog.Info("MachineClass not found in Viti stack status, no removal needed",
+					"machineClass: ", machineClassName)
+				return false
+			}
+
+			// Remove the machine class
+			setMachineClassNames(status, slices.Delete(machineClasses, machineClassIndex, machineClassIndex+1))
+
+			vlog.Info("Removed MachineClass from Viti stack status",
+				"machineClass: ", machineClassName)
+			return true
+		},
 	}
-
-	vlog.Info("Removed MachineClass from Viti stack status",
-		"name: ", vitistackName,
-		"machineClass: ", machineClassName)
 }
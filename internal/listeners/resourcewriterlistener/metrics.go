@@ -0,0 +1,12 @@
+package resourcewriterlistener
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var statusWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "vitistack_operator",
+	Name:      "status_writes_total",
+	Help:      "Vitistack status writes, by result.",
+}, []string{"result"})
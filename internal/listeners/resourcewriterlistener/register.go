@@ -1,15 +1,95 @@
 package resourcewriterlistener
 
 import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/internal/services/leaderelectionservice"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
 	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
 )
 
-// RegisterWriters registers all resource event writers with the event bus
-func RegisterWriters() {
-	eventmanager.EventBus.Subscribe("KubernetesProvider", handleKubernetesProviderEvents)
-	eventmanager.EventBus.Subscribe("MachineProvider", handleMachineProviderEvents)
-	eventmanager.EventBus.Subscribe("MachineClass", handleMachineClassEvents)
-	eventmanager.EventBus.Subscribe("KubernetesCluster", handleKubernetesClusterEvents)
-	eventmanager.EventBus.Subscribe("Machine", handleMachineEvents)
-	eventmanager.EventBus.Subscribe("ConfigMap", handleConfigMapEvents)
+var (
+	// Status write queue shared by all writers
+	writers *writeQueue
+	// Aggregator that combines the writers' status deltas into one write per interval
+	statusWriter *statusAggregator
+)
+
+// RegisterWriters registers all resource event writers with the event bus.
+// Events are not written directly; they are put on a rate-limited queue that
+// is only processed while RunWriters is running, and the writers feed their
+// status changes to an aggregator that flushes them periodically.
+func RegisterWriters(bus *eventmanager.EventManager) {
+	statusWriter = newStatusAggregator(
+		viper.GetDuration(consts.STATUS_FLUSH_INTERVAL),
+		viper.GetDuration(consts.WRITER_RETRY_BASE_DELAY),
+		viper.GetDuration(consts.WRITER_RETRY_MAX_DELAY),
+	)
+	// Writers that copy the spec and status into the Vitistack status skip updates that change
+	// neither; the machine class and machine writers only care about objects coming and going
+	changed := eventmanager.Filter{ChangedOnly: true}
+	addOrDelete := eventmanager.Filter{Types: []eventmanager.EventType{eventmanager.EventAdd, eventmanager.EventDelete}}
+	writers = newWriteQueue(map[string]writer{
+		"KubernetesProvider": {handle: handleKubernetesProviderEvents, filter: changed},
+		"MachineProvider":    {handle: handleMachineProviderEvents, filter: changed},
+		"MachineClass":       {handle: handleMachineClassEvents, filter: addOrDelete},
+		"KubernetesCluster":  {handle: handleKubernetesClusterEvents, filter: changed},
+		"Machine":            {handle: handleMachineEvents, aggregate: true, filter: addOrDelete},
+		"ConfigMap": {handle: handleConfigMapEvents, filter: eventmanager.Filter{
+			Namespaces: []string{viper.GetString(consts.NAMESPACE)},
+			Name:       viper.GetString(consts.CONFIGMAPNAME),
+		}},
+	},
+		viper.GetDuration(consts.WRITER_RETRY_BASE_DELAY),
+		viper.GetDuration(consts.WRITER_RETRY_MAX_DELAY),
+		viper.GetInt(consts.WRITER_MAX_RETRIES),
+	)
+
+	for kind, w := range writers.writers {
+		filter := w.filter
+		filter.Kinds = []string{kind}
+		bus.SubscribeFiltered(filter, writers.enqueue, eventmanager.WithName("writer/"+kind))
+		vlog.Info("Subscribed writer for resource kind: " + kind)
+	}
+}
+
+// RunWriters processes the status write queue, flushes status changes and periodically
+// rebuilds the status until ctx is cancelled. It is run while this replica is the leader and may
+// run again after leadership is regained. The pending status changes are only flushed when ctx was
+// cancelled because the operator is shutting down: after losing the lease another replica may
+// already be writing the status.
+func RunWriters(ctx context.Context) {
+	stop := ctx.Done()
+
+	var wg sync.WaitGroup
+	wg.Go(func() { writers.run(stop) })
+	wg.Go(func() { statusWriter.run(stop) })
+	wg.Go(func() { runStatusReconciler(stop, viper.GetDuration(consts.STATUS_RECONCILE_INTERVAL)) })
+	wg.Wait()
+
+	if !errors.Is(context.Cause(ctx), leaderelectionservice.ErrShuttingDown) {
+		vlog.Info("Stopped writing Viti stack status after losing leadership",
+			"pending: ", statusWriter.pendingCount())
+		return
+	}
+	if err := statusWriter.flush(); err != nil {
+		vlog.Error("Failed to flush Viti stack status", err)
+	}
+}
+
+// GetQueueStats returns the current status write queue counters
+func GetQueueStats() QueueStats {
+	if writers == nil || statusWriter == nil {
+		return QueueStats{}
+	}
+	stats := writers.stats()
+	stats.PendingStatusChanges = statusWriter.pendingCount()
+	stats.StatusFlushes = statusWriter.flushes.Load()
+	stats.StatusFlushFailures = statusWriter.flushFailures.Load()
+	stats.LastStatusFlush = statusWriter.lastFlush.Load()
+	return stats
 }
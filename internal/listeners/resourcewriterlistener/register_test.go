@@ -0,0 +1,99 @@
+package resourcewriterlistener
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/clients/k8sclient"
+	"github.com/vitistack/vitistack-operator/internal/services/leaderelectionservice"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// setupRunWriters points the writers at a fake cluster holding an empty Vitistack and returns
+// the status aggregator with one pending cluster delta
+func setupRunWriters(t *testing.T) *statusAggregator {
+	t.Helper()
+	viper.Set(consts.VITISTACKCRDNAME, "vitistack")
+	t.Cleanup(func() { viper.Set(consts.VITISTACKCRDNAME, nil) })
+
+	vitistack := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "vitistack.io/v1alpha1",
+		"kind":       "Vitistack",
+		"metadata":   map[string]any{"name": "vitistack", "resourceVersion": "1"},
+	}}
+	listKinds := map[schema.GroupVersionResource]string{vitistackGVR: "VitistackList"}
+	previous := k8sclient.DynamicClient
+	k8sclient.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, vitistack)
+	t.Cleanup(func() { k8sclient.DynamicClient = previous })
+
+	writers = newWriteQueue(map[string]writer{}, time.Millisecond, time.Second, 1)
+	statusWriter = newStatusAggregator(time.Hour, time.Millisecond, time.Second)
+	statusWriter.submit("clusters/c1", addClusterToVitistackStatus("c1", map[string]any{"name": "c1"}))
+	return statusWriter
+}
+
+// vitistackClusters returns the clusters in the status of the Vitistack in the fake cluster
+func vitistackClusters(t *testing.T) []any {
+	t.Helper()
+	obj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.Background(), "vitistack", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get Vitistack failed: %v", err)
+	}
+	clusters, _, _ := unstructured.NestedSlice(obj.Object, "status", "clusters")
+	return clusters
+}
+
+func TestRunWritersFlushesPendingStatusOnShutdown(t *testing.T) {
+	aggregator := setupRunWriters(t)
+
+	// Shutting down is signalled by cancelling the context Run was given, as main does on SIGTERM
+	ctx, shutdown := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- leaderelectionservice.Run(ctx, RunWriters) }()
+	shutdown()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after shutdown")
+	}
+
+	if flushes := aggregator.flushes.Load(); flushes != 1 {
+		t.Errorf("expected the pending status to be flushed once on shutdown, got %d", flushes)
+	}
+	if pending := aggregator.pendingCount(); pending != 0 {
+		t.Errorf("expected no pending deltas after shutdown, got %d", pending)
+	}
+	clusters := vitistackClusters(t)
+	if len(clusters) != 1 || indexByName(clusters, "c1") != 0 {
+		t.Errorf("expected c1 in the flushed status, got %v", clusters)
+	}
+}
+
+func TestRunWritersDoesNotFlushAfterLosingLeadership(t *testing.T) {
+	aggregator := setupRunWriters(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	RunWriters(ctx)
+
+	if flushes := aggregator.flushes.Load(); flushes != 0 {
+		t.Errorf("expected no flush after losing leadership, got %d", flushes)
+	}
+	if pending := aggregator.pendingCount(); pending != 1 {
+		t.Errorf("expected the delta to stay pending, got %d", pending)
+	}
+	if clusters := vitistackClusters(t); len(clusters) != 0 {
+		t.Errorf("expected the status to be left alone, got %v", clusters)
+	}
+}
@@ -0,0 +1,284 @@
+package resourcewriterlistener
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/clients/k8sclient"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// statusDelta is one pending change to the Vitistack status
+type statusDelta struct {
+	// fields are the top-level status fields the delta owns. Only owned fields are
+	// sent in the status patch, so a writer can never overwrite another writer's part.
+	fields []string
+	// apply changes the status map in place and reports whether it was modified
+	apply func(status map[string]any) bool
+	// sequence is the order the delta was submitted in, set by the aggregator
+	sequence uint64
+}
+
+// flushKey is the rate limiter key used to back off failed flushes
+const flushKey = "vitistack-status-flush"
+
+// statusAggregator collects status deltas from the writers and flushes them as
+// one combined status write per interval. Deltas are keyed by the status entry
+// they touch, e.g. "clusters/prod-1", so a newer delta for the same entry
+// replaces the older one before it is ever written. Deltas are applied in the
+// order they were submitted, so a reconcile built before a newer per-entry delta
+// can not undo it.
+type statusAggregator struct {
+	interval time.Duration
+	backoff  workqueue.TypedRateLimiter[string]
+
+	mutex    sync.Mutex
+	pending  map[string]statusDelta
+	sequence uint64
+
+	// flushMutex serializes flushes from the run loop and from shutdown
+	flushMutex sync.Mutex
+
+	flushes       atomic.Int64
+	flushFailures atomic.Int64
+	lastFlush     atomic.Int64
+}
+
+// newStatusAggregator creates an aggregator that flushes every interval and backs off
+// between baseDelay and maxDelay while flushes keep failing
+func newStatusAggregator(interval, baseDelay, maxDelay time.Duration) *statusAggregator {
+	return &statusAggregator{
+		interval: interval,
+		backoff:  workqueue.NewTypedItemExponentialFailureRateLimiter[string](baseDelay, maxDelay),
+		pending:  make(map[string]statusDelta),
+	}
+}
+
+// submit records a delta for the given status entry, replacing any pending delta for the same entry
+func (a *statusAggregator) submit(key string, delta statusDelta) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.sequence++
+	delta.sequence = a.sequence
+	a.pending[key] = delta
+}
+
+// run flushes pending deltas every interval until stop is closed.
+// A failed flush keeps its deltas and is retried with exponential backoff.
+func (a *statusAggregator) run(stop <-chan struct{}) {
+	timer := time.NewTimer(a.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			delay := a.interval
+			if err := a.flush(); err != nil {
+				delay = max(a.interval, a.backoff.When(flushKey))
+				vlog.Warn("Failed to flush Vitistack status, retrying",
+					"retry: ", a.backoff.NumRequeues(flushKey),
+					"delay: ", delay.String(),
+					"error: ", err.Error())
+			} else {
+				a.backoff.Forget(flushKey)
+			}
+			timer.Reset(delay)
+		}
+	}
+}
+
+// flush writes all pending deltas to the Vitistack status in a single update.
+// On failure the deltas are put back unless a newer delta for the same entry arrived meanwhile.
+func (a *statusAggregator) flush() error {
+	a.flushMutex.Lock()
+	defer a.flushMutex.Unlock()
+
+	a.mutex.Lock()
+	deltas := a.pending
+	a.pending = make(map[string]statusDelta)
+	a.mutex.Unlock()
+
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	err := writeStatusDeltas(deltas)
+	if err != nil {
+		statusWrites.WithLabelValues("failure").Inc()
+		a.flushFailures.Add(1)
+		a.mutex.Lock()
+		for key, delta := range deltas {
+			if _, newer := a.pending[key]; !newer {
+				a.pending[key] = delta
+			}
+		}
+		a.mutex.Unlock()
+		return err
+	}
+
+	statusWrites.WithLabelValues("success").Inc()
+	a.flushes.Add(1)
+	a.lastFlush.Store(time.Now().Unix())
+	return nil
+}
+
+// pendingCount returns the number of deltas waiting for the next flush
+func (a *statusAggregator) pendingCount() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.pending)
+}
+
+// writeStatusDeltas applies the deltas to the latest Vitistack status and sends the owned
+// fields that changed as a JSON merge patch of the status subresource. The patch carries the
+// resourceVersion it was computed from, so a concurrent write by another writer or replica
+// results in a conflict, which is retried against the fresh object.
+func writeStatusDeltas(deltas map[string]statusDelta) error {
+	if k8sclient.DynamicClient == nil {
+		return errors.New("dynamic client is not initialized")
+	}
+
+	vitistackCrdName := viper.GetString(consts.VITISTACKCRDNAME)
+
+	keys := submissionOrder(deltas)
+
+	var patched *unstructured.Unstructured
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latestObj, err := getOrCreateVitistackCrd(vitistackCrdName)
+		if err != nil {
+			return fmt.Errorf("failed to get or create Viti stack CRD %s: %w", vitistackCrdName, err)
+		}
+
+		patch, err := buildStatusPatch(latestObj, deltas, keys)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		patched, err = k8sclient.DynamicClient.Resource(vitistackGVR).Patch(context.TODO(), latestObj.GetName(),
+			types.MergePatchType, patch, metav1.PatchOptions{FieldManager: statusFieldManager}, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch Viti stack CRD %s status: %w", vitistackCrdName, err)
+	}
+
+	if patched != nil {
+		vlog.Info("Flushed Viti stack status",
+			"name: ", patched.GetName(),
+			"changes: ", len(deltas))
+	}
+	return nil
+}
+
+// submissionOrder returns the keys of the deltas in the order the deltas were submitted.
+// Later deltas reflect newer state and must be applied last.
+func submissionOrder(deltas map[string]statusDelta) []string {
+	keys := make([]string, 0, len(deltas))
+	for key := range deltas {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(deltas[a].sequence, deltas[b].sequence), strings.Compare(a, b))
+	})
+	return keys
+}
+
+// buildStatusPatch applies the deltas to a copy of the object's status and returns a merge patch
+// with the owned fields that changed, or nil if nothing changed. Status fields that were missing
+// are defaulted and included as well, since no writer owns them yet.
+func buildStatusPatch(obj *unstructured.Unstructured, deltas map[string]statusDelta, keys []string) ([]byte, error) {
+	original, _, _ := unstructured.NestedMap(obj.Object, "status")
+	if original == nil {
+		original = map[string]any{}
+	}
+	status := runtime.DeepCopyJSON(original)
+	initializeStatusDefaults(status)
+
+	owned := make(map[string]bool)
+	for field := range status {
+		if _, exists := original[field]; !exists {
+			owned[field] = true
+		}
+	}
+	for _, key := range keys {
+		// Each delta works on its own copy and only its owned fields are taken from it
+		scratch := runtime.DeepCopyJSON(status)
+		if !deltas[key].apply(scratch) {
+			continue
+		}
+		for _, field := range deltas[key].fields {
+			owned[field] = true
+			if value, exists := scratch[field]; exists {
+				status[field] = value
+			} else {
+				delete(status, field)
+			}
+		}
+	}
+
+	statusPatch := make(map[string]any)
+	for field := range owned {
+		value, exists := status[field]
+		switch {
+		case !exists:
+			if _, existed := original[field]; existed {
+				statusPatch[field] = nil
+			}
+		case !reflect.DeepEqual(original[field], value):
+			statusPatch[field] = value
+		}
+	}
+
+	if len(statusPatch) == 0 {
+		return nil, nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"resourceVersion": obj.GetResourceVersion(),
+		},
+		"status": statusPatch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal status patch: %w", err)
+	}
+	return patch, nil
+}
+
+// statusList returns the list stored under field in the status, or an empty list if it is missing
+func statusList(status map[string]any, field string) []any {
+	list, ok := status[field].([]any)
+	if !ok {
+		return []any{}
+	}
+	return list
+}
+
+// indexByName returns the index of the entry with the given name in a status list, or -1
+func indexByName(list []any, name string) int {
+	for i, item := range list {
+		if itemMap, ok := item.(map[string]any); ok && itemMap["name"] == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,168 @@
+package resourcewriterlistener
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusAggregatorReplacesPendingDeltaForSameEntry(t *testing.T) {
+	a := newStatusAggregator(time.Second, time.Millisecond, time.Second)
+
+	a.submit("clusters/c1", addClusterToVitistackStatus("c1", map[string]any{"name": "c1"}))
+	a.submit("clusters/c1", removeClusterFromVitistackStatus("c1"))
+	a.submit("clusters/c2", addClusterToVitistackStatus("c2", map[string]any{"name": "c2"}))
+
+	if count := a.pendingCount(); count != 2 {
+		t.Fatalf("expected 2 pending deltas, got %d", count)
+	}
+
+	status := map[string]any{
+		"clusters": []any{map[string]any{"name": "c1"}},
+	}
+	for _, key := range []string{"clusters/c1", "clusters/c2"} {
+		a.pending[key].apply(status)
+	}
+
+	clusters := statusList(status, "clusters")
+	if len(clusters) != 1 || indexByName(clusters, "c2") != 0 {
+		t.Errorf("expected only c2 in clusters, got %v", clusters)
+	}
+	if status["activeClusters"] != int64(1) {
+		t.Errorf("expected activeClusters 1, got %v", status["activeClusters"])
+	}
+}
+
+func TestAddProviderDeltaPreservesDiscoveredAt(t *testing.T) {
+	status := map[string]any{
+		MachineProviderType: []any{
+			map[string]any{"name": "mp", "namespace": "default", "ready": false, "discoveredAt": "2024-01-01T00:00:00Z"},
+		},
+	}
+
+	changed := addProviderToVitistackStatus("mp", MachineProviderType, map[string]any{
+		"name": "mp", "namespace": "default", "ready": true, "discoveredAt": "2025-01-01T00:00:00Z",
+	}).apply(status)
+	if !changed {
+		t.Fatal("expected provider update to change the status")
+	}
+
+	provider := statusList(status, MachineProviderType)[0].(map[string]any)
+	if provider["discoveredAt"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected discoveredAt to be preserved, got %v", provider["discoveredAt"])
+	}
+	if status["machineProviderCount"] != int64(1) {
+		t.Errorf("expected machineProviderCount 1, got %v", status["machineProviderCount"])
+	}
+
+	if addProviderToVitistackStatus("mp", MachineProviderType, map[string]any{
+		"name": "mp", "namespace": "default", "ready": true,
+	}).apply(status) {
+		t.Error("expected unchanged provider metadata to be a no-op")
+	}
+}
+
+func TestBuildStatusPatchOnlyContainsOwnedChangedFields(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "vs", "resourceVersion": "42"},
+		"status": map[string]any{
+			"clusters":       []any{map[string]any{"name": "c1"}},
+			"activeClusters": int64(1),
+			"activeMachines": int64(3),
+		},
+	}}
+
+	// A delta that touches a field it does not own must not leak that field into the patch
+	rogue := statusDelta{
+		fields: machineStatusFields,
+		apply: func(status map[string]any) bool {
+			status["activeClusters"] = int64(99)
+			return true
+		},
+	}
+	deltas := map[string]statusDelta{
+		"clusters/c2": addClusterToVitistackStatus("c2", map[string]any{"name": "c2"}),
+		"rogue":       rogue,
+	}
+
+	data, err := buildStatusPatch(obj, deltas, []string{"clusters/c2", "rogue"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var patch struct {
+		Metadata map[string]any `json:"metadata"`
+		Status   map[string]any `json:"status"`
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		t.Fatalf("failed to decode patch: %v", err)
+	}
+
+	if patch.Metadata["resourceVersion"] != "42" {
+		t.Errorf("expected resourceVersion 42 in patch, got %v", patch.Metadata["resourceVersion"])
+	}
+	if clusters, ok := patch.Status[ClusterType].([]any); !ok || len(clusters) != 2 {
+		t.Errorf("expected both clusters in patch, got %v", patch.Status[ClusterType])
+	}
+	if _, ok := patch.Status["activeMachines"]; ok {
+		t.Error("expected unchanged activeMachines to be left out of the patch")
+	}
+	if patch.Status["activeClusters"] != float64(2) {
+		t.Errorf("expected activeClusters 2 from the owning delta, got %v", patch.Status["activeClusters"])
+	}
+	if _, ok := patch.Status["machineProviders"]; !ok {
+		t.Error("expected missing status fields to be defaulted in the patch")
+	}
+
+	obj.Object["status"] = map[string]any{}
+	if data, _ := buildStatusPatch(obj, map[string]statusDelta{}, nil); data == nil {
+		t.Error("expected an empty status to be initialized with defaults")
+	}
+}
+
+func TestReconcileDoesNotUndoNewerDeltaInSameFlush(t *testing.T) {
+	a := newStatusAggregator(time.Second, time.Millisecond, time.Second)
+
+	// The reconcile snapshot is taken before c2 is created, and c2's delta arrives in the same window
+	a.submit("reconcile", replaceDriftedStatus(map[string]any{
+		ClusterType: []map[string]any{{"name": "c1"}},
+	}))
+	a.submit("clusters/c2", addClusterToVitistackStatus("c2", map[string]any{"name": "c2"}))
+
+	keys := submissionOrder(a.pending)
+	if len(keys) != 2 || keys[0] != "reconcile" || keys[1] != "clusters/c2" {
+		t.Fatalf("expected deltas in submission order, got %v", keys)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "vs", "resourceVersion": "1"},
+		"status": map[string]any{
+			"clusters":       []any{map[string]any{"name": "c1"}},
+			"activeClusters": int64(1),
+		},
+	}}
+	data, err := buildStatusPatch(obj, a.pending, keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var patch struct {
+		Status map[string]any `json:"status"`
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		t.Fatalf("failed to decode patch: %v", err)
+	}
+	clusters, _ := patch.Status[ClusterType].([]any)
+	if len(clusters) != 2 || indexByName(clusters, "c2") < 0 {
+		t.Errorf("expected the newer cluster to survive the reconcile, got %v", patch.Status[ClusterType])
+	}
+
+	// A newer reconcile replaces the older one and is applied after the cluster delta
+	a.submit("reconcile", replaceDriftedStatus(map[string]any{
+		ClusterType: []map[string]any{{"name": "c1"}, {"name": "c2"}},
+	}))
+	if keys := submissionOrder(a.pending); keys[0] != "clusters/c2" || keys[1] != "reconcile" {
+		t.Errorf("expected the resubmitted reconcile to be applied last, got %v", keys)
+	}
+}
@@ -0,0 +1,244 @@
+package resourcewriterlistener
+
+import (
+	"slices"
+	"time"
+
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// reconciledStatusFields are the status fields owned by the reconciler
+var reconciledStatusFields = []string{
+	KubernetesProviderType, "kubernetesProviderCount",
+	MachineProviderType, "machineProviderCount",
+	MachineClassType,
+	ClusterType, "activeClusters",
+	"activeMachines",
+}
+
+// runStatusReconciler periodically rebuilds the Vitistack status from the informers' full state.
+// The writers only apply deltas, so a missed delete event or a manual edit of the status would
+// otherwise leave stale entries behind forever.
+func runStatusReconciler(stop <-chan struct{}, interval time.Duration) {
+	if interval <= 0 {
+		vlog.Info("Periodic Viti stack status reconcile is disabled")
+		return
+	}
+
+	// A rebuild from a partially listed store would remove live entries
+	if !cache.WaitForCacheSync(stop, dynamicclienthandler.HasSynced) {
+		return
+	}
+
+	wait.Until(reconcileVitistackStatus, interval, stop)
+}
+
+// reconcileVitistackStatus builds the desired status sections from the informer stores and
+// submits them to the status aggregator, which replaces the ones that have drifted
+func reconcileVitistackStatus() {
+	desired := make(map[string]any)
+
+	if providers, ok := dynamicclienthandler.ListSynced(kubernetesProviderGVR); ok {
+		desired[KubernetesProviderType] = buildStatusEntries(providers, extractProviderMetadata)
+	}
+	if providers, ok := dynamicclienthandler.ListSynced(machineProviderGVR); ok {
+		desired[MachineProviderType] = buildStatusEntries(providers, extractProviderMetadata)
+	}
+	if machineClasses, ok := dynamicclienthandler.ListSynced(machineClassGVR); ok {
+		names := make([]string, 0, len(machineClasses))
+		for _, mc := range machineClasses {
+			names = append(names, mc.GetName())
+		}
+		slices.Sort(names)
+		desired[MachineClassType] = names
+	}
+	if clusters, ok := dynamicclienthandler.ListSynced(kubernetesClusterGVR); ok {
+		desired[ClusterType] = buildStatusEntries(clusters, extractClusterMetadata)
+	}
+	if machines, ok := dynamicclienthandler.ListSynced(machineGVR); ok {
+		desired["activeMachines"] = int64(len(machines))
+	}
+
+	if len(desired) == 0 {
+		return
+	}
+
+	statusWriter.submit("reconcile", replaceDriftedStatus(desired))
+}
+
+// buildStatusEntries converts objects to status entries sorted by name
+func buildStatusEntries(objects []*unstructured.Unstructured, extract func(*unstructured.Unstructured) map[string]any) []map[string]any {
+	entries := make([]map[string]any, 0, len(objects))
+	for _, obj := range objects {
+		entries = append(entries, extract(obj))
+	}
+	slices.SortFunc(entries, func(a, b map[string]any) int {
+		aName, _ := a["name"].(string)
+		bName, _ := b["name"].(string)
+		if aName < bName {
+			return -1
+		}
+		if aName > bName {
+			return 1
+		}
+		return 0
+	})
+	return entries
+}
+
+// replaceDriftedStatus returns a delta that replaces every status section in desired that
+// differs from the current status, logging what was corrected
+func replaceDriftedStatus(desired map[string]any) statusDelta {
+	return statusDelta{
+		fields: reconciledStatusFields,
+		apply: func(status map[string]any) bool {
+			changed := false
+
+			for _, providerType := range []string{KubernetesProviderType, MachineProviderType} {
+				entries, ok := desired[providerType].([]map[string]any)
+				if !ok {
+					continue
+				}
+				if reconcileEntries(status, providerType, entries, providerMetadataEqual) {
+					changed = true
+				}
+				if setCount(status, getProviderCountField(providerType), int64(len(entries))) {
+					changed = true
+				}
+			}
+
+			if entries, ok := desired[ClusterType].([]map[string]any); ok {
+				if reconcileEntries(status, ClusterType, entries, clusterMetadataEqual) {
+					changed = true
+				}
+				if setCount(status, "activeClusters", int64(len(entries))) {
+					changed = true
+				}
+			}
+
+			if names, ok := desired[MachineClassType].([]string); ok {
+				if reconcileMachineClasses(status, names) {
+					changed = true
+				}
+			}
+
+			if count, ok := desired["activeMachines"].(int64); ok {
+				if setCount(status, "activeMachines", count) {
+					changed = true
+				}
+			}
+
+			return changed
+		},
+	}
+}
+
+// reconcileEntries replaces the status list under field with the desired entries if they differ.
+// Existing entries keep their position and discoveredAt timestamp; new entries are appended.
+func reconcileEntries(status map[string]any, field string, desired []map[string]any, equal func(existing, new map[string]any) bool) bool {
+	existing := statusList(status, field)
+
+	desiredByName := make(map[string]map[string]any, len(desired))
+	for _, entry := range desired {
+		name, _ := entry["name"].(string)
+		desiredByName[name] = entry
+	}
+
+	var added, removed, updated []string
+	result := make([]any, 0, len(desired))
+	seen := make(map[string]bool, len(existing))
+
+	for _, item := range existing {
+		itemMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := itemMap["name"].(string)
+		entry, ok := desiredByName[name]
+		if !ok || seen[name] {
+			removed = append(removed, name)
+			continue
+		}
+		seen[name] = true
+		if !equal(itemMap, entry) {
+			updated = append(updated, name)
+		}
+		if discoveredAt, ok := itemMap["discoveredAt"]; ok {
+			entry["discoveredAt"] = discoveredAt
+		}
+		result = append(result, entry)
+	}
+
+	for _, entry := range desired {
+		name, _ := entry["name"].(string)
+		if !seen[name] {
+			added = append(added, name)
+			result = append(result, entry)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 && len(updated) == 0 {
+		return false
+	}
+
+	status[field] = result
+	vlog.Warn("Corrected drift in Viti stack status",
+		"field: ", field,
+		"added: ", added,
+		"removed: ", removed,
+		"updated: ", updated)
+	return true
+}
+
+// reconcileMachineClasses replaces the machine class names in the status if they differ from desired
+func reconcileMachineClasses(status map[string]any, desired []string) bool {
+	existing := machineClassNames(status)
+
+	var added, removed []string
+	result := make([]string, 0, len(desired))
+	for _, name := range existing {
+		if slices.Contains(desired, name) && !slices.Contains(result, name) {
+			result = append(result, name)
+		} else {
+			removed = append(removed, name)
+		}
+	}
+	for _, name := range desired {
+		if !slices.Contains(result, name) {
+			added = append(added, name)
+			result = append(result, name)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		return false
+	}
+
+	setMachineClassNames(status, result)
+	vlog.Warn("Corrected drift in Viti stack status",
+		"field: ", MachineClassType,
+		"added: ", added,
+		"removed: ", removed)
+	return true
+}
+
+// setCount sets a count field in the status and reports whether it differed
+func setCount(status map[string]any, field string, count int64) bool {
+	if field == "" {
+		return false
+	}
+	current, found := status[field].(int64)
+	if found && current == count {
+		return false
+	}
+	status[field] = count
+	vlog.Warn("Corrected drift in Viti stack status",
+		"field: ", field,
+		"previous: ", current,
+		"current: ", count)
+	return true
+}
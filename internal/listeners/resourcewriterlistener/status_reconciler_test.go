@@ -0,0 +1,46 @@
+package resourcewriterlistener
+
+import "testing"
+
+func TestReplaceDriftedStatusCorrectsStaleEntries(t *testing.T) {
+	status := map[string]any{
+		ClusterType: []any{
+			map[string]any{"name": "deleted", "discoveredAt": "2024-01-01T00:00:00Z"},
+			map[string]any{"name": "kept", "phase": "Pending", "discoveredAt": "2024-01-01T00:00:00Z"},
+		},
+		"activeClusters": int64(2),
+		"activeMachines": int64(7),
+	}
+
+	changed := replaceDriftedStatus(map[string]any{
+		ClusterType: []map[string]any{
+			{"name": "kept", "phase": "Ready", "discoveredAt": "2025-01-01T00:00:00Z"},
+			{"name": "new", "discoveredAt": "2025-01-01T00:00:00Z"},
+		},
+		"activeMachines": int64(3),
+	}).apply(status)
+	if !changed {
+		t.Fatal("expected drifted status to be corrected")
+	}
+
+	clusters := statusList(status, ClusterType)
+	if len(clusters) != 2 || indexByName(clusters, "kept") != 0 || indexByName(clusters, "new") != 1 {
+		t.Fatalf("expected clusters [kept new], got %v", clusters)
+	}
+	kept := clusters[0].(map[string]any)
+	if kept["phase"] != "Ready" || kept["discoveredAt"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected kept to be updated with its original discoveredAt, got %v", kept)
+	}
+	if status["activeClusters"] != int64(2) || status["activeMachines"] != int64(3) {
+		t.Errorf("expected counts 2 and 3, got %v and %v", status["activeClusters"], status["activeMachines"])
+	}
+
+	if replaceDriftedStatus(map[string]any{
+		ClusterType: []map[string]any{
+			{"name": "kept", "phase": "Ready"},
+			{"name": "new"},
+		},
+	}).apply(status) {
+		t.Error("expected a status without drift to be left unchanged")
+	}
+}
@@ -2,6 +2,7 @@ package resourcewriterlistener
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/spf13/viper"
@@ -9,34 +10,25 @@ import (
 	"github.com/vitistack/common/pkg/loggers/vlog"
 	"github.com/vitistack/vitistack-operator/internal/services/vitistacknameservice"
 	"github.com/vitistack/vitistack-operator/pkg/consts"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // getOrCreateVitistackCrd tries to get an existing vitistack CRD or creates a new one if it doesn't exist
 func getOrCreateVitistackCrd(name string) (*unstructured.Unstructured, error) {
-	// Use a read lock first since we're just checking if the vitistack exists
-	vitistackRWMutex.RLock()
 	vitistackObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.TODO(), name, metav1.GetOptions{})
-	vitistackRWMutex.RUnlock()
-
 	if err == nil {
 		return vitistackObj, nil
 	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
 
 	// Get vitistack information from ConfigMap
 	vitistackName, region, country, zone, infrastructure := getVitistackInfoFromConfigMap()
 
-	// If the vitistack doesn't exist, we need to create it - acquire a write lock
-	vitistackRWMutex.Lock()
-	defer vitistackRWMutex.Unlock()
-
-	// Check again in case another goroutine created the vitistack while we were waiting for the lock
-	vitistackObj, err = k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.TODO(), name, metav1.GetOptions{})
-	if err == nil {
-		return vitistackObj, nil
-	}
-
 	// Build location object if location is provided
 	var locationObj map[string]any
 	if country != "" {
@@ -76,7 +68,11 @@ func getOrCreateVitistackCrd(name string) (*unstructured.Unstructured, error) {
 		},
 	}
 
-	createdObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Create(context.TODO(), vitistack, metav1.CreateOptions{})
+	createdObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Create(context.TODO(), vitistack, metav1.CreateOptions{FieldManager: statusFieldManager})
+	if apierrors.IsAlreadyExists(err) {
+		// Another writer or replica created it first
+		return k8sclient.DynamicClient.Resource(vitistackGVR).Get(context.TODO(), name, metav1.GetOptions{})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -118,14 +114,15 @@ func getOrCreateVitistackCrd(name string) (*unstructured.Unstructured, error) {
 		status["location"] = locationObj
 	}
 
-	err = unstructured.SetNestedField(createdObj.Object, status, "status")
+	patch, err := json.Marshal(map[string]any{"status": status})
 	if err != nil {
-		vlog.Error("Failed to set initial status in vitistack", err)
+		vlog.Error("Failed to marshal initial status in vitistack", err)
 		return createdObj, nil // Return the created object even if status update fails
 	}
 
-	// Update status
-	updatedObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).UpdateStatus(context.TODO(), createdObj, metav1.UpdateOptions{})
+	// Patch status
+	updatedObj, err := k8sclient.DynamicClient.Resource(vitistackGVR).Patch(context.TODO(), name,
+		types.MergePatchType, patch, metav1.PatchOptions{FieldManager: statusFieldManager}, "status")
 	if err != nil {
 		vlog.Error("Failed to update initial Vitistack status", err)
 		return createdObj, nil // Return the created object even if status update fails
@@ -0,0 +1,218 @@
+package resourcewriterlistener
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// writerFunc applies a single resource event to the Vitistack status.
+// A returned error causes the event to be requeued with backoff.
+type writerFunc func(event eventmanager.ResourceEvent) error
+
+// writer describes how events of one resource kind are written
+type writer struct {
+	handle writerFunc
+	// aggregate collapses every event of the kind into a single queue item.
+	// Used by writers that recompute from the full set of objects rather than
+	// from the object carried by the event.
+	aggregate bool
+	// filter selects the events of the kind that are written; its Kinds are set at registration
+	filter eventmanager.Filter
+}
+
+// QueueStats is a point-in-time view of the status write queue and status aggregator
+type QueueStats struct {
+	Depth     int   `json:"depth"`
+	Pending   int   `json:"pending"`
+	Processed int64 `json:"processed"`
+	Retries   int64 `json:"retries"`
+	Failures  int64 `json:"failures"`
+	Dropped   int64 `json:"dropped"`
+
+	PendingStatusChanges int   `json:"pendingStatusChanges"`
+	StatusFlushes        int64 `json:"statusFlushes"`
+	StatusFlushFailures  int64 `json:"statusFlushFailures"`
+	LastStatusFlush      int64 `json:"lastStatusFlush"`
+}
+
+// writeQueue sits between the event bus and the status writers. Events are
+// keyed by kind/namespace/name so that a burst of events for the same object
+// collapses into one write of its latest state, and a failed write is requeued
+// with exponential backoff instead of being lost until the next informer event.
+//
+// Events are collected at all times, but only written while run is active, i.e.
+// while this replica is the leader. Each run gets a fresh workqueue seeded with
+// every pending key, so a new leader writes the latest state it has seen.
+type writeQueue struct {
+	writers    map[string]writer
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxRetries int
+
+	mutex   sync.Mutex
+	queue   workqueue.TypedRateLimitingInterface[string]
+	pending map[string]eventmanager.ResourceEvent
+
+	processed atomic.Int64
+	retries   atomic.Int64
+	failures  atomic.Int64
+	dropped   atomic.Int64
+}
+
+// newWriteQueue creates a write queue for the given writers, keyed by resource kind.
+// maxRetries of 0 retries a failing event until it succeeds.
+func newWriteQueue(writers map[string]writer, baseDelay, maxDelay time.Duration, maxRetries int) *writeQueue {
+	return &writeQueue{
+		writers:    writers,
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+		maxRetries: maxRetries,
+		pending:    make(map[string]eventmanager.ResourceEvent),
+	}
+}
+
+// enqueue records the event as the latest state for its key and schedules it for processing
+func (wq *writeQueue) enqueue(event eventmanager.ResourceEvent) {
+	if event.Resource == nil {
+		vlog.Error("Cannot enqueue event with nil resource", nil)
+		return
+	}
+
+	key := wq.keyFor(event)
+
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+	wq.pending[key] = event
+	if wq.queue != nil {
+		wq.queue.Add(key)
+	}
+}
+
+// keyFor returns the queue key for an event
+func (wq *writeQueue) keyFor(event eventmanager.ResourceEvent) string {
+	kind := event.Resource.GetKind()
+	if w, ok := wq.writers[kind]; ok && w.aggregate {
+		return kind
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, event.Resource.GetNamespace(), event.Resource.GetName())
+}
+
+// run processes queued events until stop is closed and returns once the in-flight write has finished.
+// A single worker is used on purpose: every writer updates the same Vitistack
+// object, so parallel workers would only produce resourceVersion conflicts.
+func (wq *writeQueue) run(stop <-chan struct{}) {
+	rateLimiter := workqueue.NewTypedItemExponentialFailureRateLimiter[string](wq.baseDelay, wq.maxDelay)
+	queue := workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[string]{
+		Name: "vitistack-status-writer",
+	})
+
+	wq.mutex.Lock()
+	for key := range wq.pending {
+		queue.Add(key)
+	}
+	wq.queue = queue
+	wq.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for wq.processNextItem(queue) {
+		}
+	}()
+
+	<-stop
+
+	wq.mutex.Lock()
+	wq.queue = nil
+	wq.mutex.Unlock()
+
+	// Unprocessed events stay in pending for the next run
+	queue.ShutDown()
+	<-done
+}
+
+// processNextItem handles one queue item and reports whether the worker should continue
+func (wq *writeQueue) processNextItem(queue workqueue.TypedRateLimitingInterface[string]) bool {
+	key, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(key)
+
+	wq.mutex.Lock()
+	event, ok := wq.pending[key]
+	delete(wq.pending, key)
+	wq.mutex.Unlock()
+
+	if !ok {
+		queue.Forget(key)
+		return true
+	}
+
+	w, ok := wq.writers[event.Resource.GetKind()]
+	if !ok {
+		vlog.Error("No status writer registered for resource kind", nil,
+			"kind: ", event.Resource.GetKind())
+		queue.Forget(key)
+		return true
+	}
+
+	err := w.handle(event)
+	wq.processed.Add(1)
+	if err == nil {
+		queue.Forget(key)
+		return true
+	}
+
+	wq.failures.Add(1)
+	requeues := queue.NumRequeues(key)
+	if wq.maxRetries > 0 && requeues >= wq.maxRetries {
+		wq.dropped.Add(1)
+		queue.Forget(key)
+		vlog.Error("Giving up on Vitistack status write after max retries", err,
+			"key: ", key,
+			"retries: ", requeues)
+		return true
+	}
+
+	// Keep the failed event unless a newer one for the same key arrived while we were writing
+	wq.mutex.Lock()
+	if _, newer := wq.pending[key]; !newer {
+		wq.pending[key] = event
+	}
+	wq.mutex.Unlock()
+
+	wq.retries.Add(1)
+	queue.AddRateLimited(key)
+	vlog.Warn("Failed to write Vitistack status, requeueing",
+		"key: ", key,
+		"retry: ", requeues+1,
+		"error: ", err.Error())
+	return true
+}
+
+// stats returns the current queue counters
+func (wq *writeQueue) stats() QueueStats {
+	wq.mutex.Lock()
+	pending := len(wq.pending)
+	depth := 0
+	if wq.queue != nil {
+		depth = wq.queue.Len()
+	}
+	wq.mutex.Unlock()
+
+	return QueueStats{
+		Depth:     depth,
+		Pending:   pending,
+		Processed: wq.processed.Load(),
+		Retries:   wq.retries.Load(),
+		Failures:  wq.failures.Load(),
+		Dropped:   wq.dropped.Load(),
+	}
+}
@@ -0,0 +1,119 @@
+package resourcewriterlistener
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestEvent(kind, name string, eventType eventmanager.EventType) eventmanager.ResourceEvent {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	return eventmanager.ResourceEvent{Type: eventType, Resource: obj}
+}
+
+func TestWriteQueueRequeuesFailedWrites(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	done := make(chan struct{})
+
+	wq := newWriteQueue(map[string]writer{
+		"MachineProvider": {handle: func(event eventmanager.ResourceEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			if attempts < 3 {
+				return errors.New("conflict")
+			}
+			close(done)
+			return nil
+		}},
+	}, time.Millisecond, 10*time.Millisecond, 0)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go wq.run(stop)
+
+	wq.enqueue(newTestEvent("MachineProvider", "mp-1", eventmanager.EventAdd))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write was not retried until it succeeded")
+	}
+
+	stats := wq.stats()
+	if stats.Retries != 2 {
+		t.Errorf("expected 2 retries, got %d", stats.Retries)
+	}
+	if stats.Dropped != 0 {
+		t.Errorf("expected no dropped events, got %d", stats.Dropped)
+	}
+}
+
+func TestWriteQueueKeepsLatestEventPerKey(t *testing.T) {
+	wq := newWriteQueue(map[string]writer{
+		"KubernetesCluster": {handle: func(eventmanager.ResourceEvent) error { return nil }},
+		"Machine":           {handle: func(eventmanager.ResourceEvent) error { return nil }, aggregate: true},
+	}, time.Millisecond, 10*time.Millisecond, 0)
+
+	wq.enqueue(newTestEvent("KubernetesCluster", "c1", eventmanager.EventAdd))
+	wq.enqueue(newTestEvent("KubernetesCluster", "c1", eventmanager.EventDelete))
+	wq.enqueue(newTestEvent("Machine", "m1", eventmanager.EventAdd))
+	wq.enqueue(newTestEvent("Machine", "m2", eventmanager.EventAdd))
+
+	if pending := len(wq.pending); pending != 2 {
+		t.Fatalf("expected 2 pending keys, got %d", pending)
+	}
+	if event := wq.pending["KubernetesCluster/default/c1"]; event.Type != eventmanager.EventDelete {
+		t.Errorf("expected latest event to be DELETE, got %s", event.Type)
+	}
+}
+
+func TestWriteQueueWritesEventsCollectedBeforeRun(t *testing.T) {
+	written := make(chan string, 1)
+	wq := newWriteQueue(map[string]writer{
+		"KubernetesCluster": {handle: func(event eventmanager.ResourceEvent) error {
+			written <- event.Resource.GetName()
+			return nil
+		}},
+	}, time.Millisecond, 10*time.Millisecond, 0)
+
+	// Events arrive while this replica is not the leader
+	wq.enqueue(newTestEvent("KubernetesCluster", "c1", eventmanager.EventAdd))
+
+	stop := make(chan struct{})
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		wq.run(stop)
+	}()
+
+	select {
+	case name := <-written:
+		if name != "c1" {
+			t.Errorf("expected c1 to be written, got %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event collected before run was not written")
+	}
+
+	close(stop)
+	<-runDone
+
+	wq.enqueue(newTestEvent("KubernetesCluster", "c2", eventmanager.EventAdd))
+	select {
+	case name := <-written:
+		t.Fatalf("expected no writes after run returned, got %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if stats := wq.stats(); stats.Pending != 1 || stats.Depth != 0 {
+		t.Errorf("expected 1 pending event and an empty queue, got %+v", stats)
+	}
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/vitistack/common/pkg/clients/k8sclient"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The admin endpoints are authorized as subresources of a resource that is not served by the
+// API server, so RBAC grants them like any other resource. The chart's admin ClusterRole grants
+// get, update and delete on operatoradmin/cache and operatoradmin/deadletters.
+const (
+	AdminGroup    = "vitistack.io"
+	AdminResource = "operatoradmin"
+)
+
+// adminVerbs are the verbs the HTTP methods of the admin endpoints are authorized for
+var adminVerbs = map[string]string{
+	http.MethodGet:    "get",
+	http.MethodPost:   "update",
+	http.MethodDelete: "delete",
+}
+
+// reviewAccess asks the API server whether the user may perform the action on the resource
+var reviewAccess = func(ctx context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}
+	result, err := k8sclient.Kubernetes.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+// AdminMiddleware returns a middleware that only lets a request through if its user is allowed the
+// verb of the request's method on the given subresource of AdminResource, checked with a
+// SubjectAccessReview. It must run after AuthMiddleware.
+func AdminMiddleware(subresource string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := userFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized: Request is not authenticated", http.StatusUnauthorized)
+				return
+			}
+			verb, ok := adminVerbs[r.Method]
+			if !ok {
+				http.Error(w, "Forbidden: Method is not allowed on admin endpoints", http.StatusForbidden)
+				return
+			}
+
+			allowed, err := reviewAccess(r.Context(), user, authorizationv1.ResourceAttributes{
+				Group:       AdminGroup,
+				Resource:    AdminResource,
+				Subresource: subresource,
+				Verb:        verb,
+			})
+			if err != nil {
+				vlog.Error("Failed to review access to admin endpoint", err)
+				http.Error(w, "Unable to check access", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				vlog.Warn("Denied access to admin endpoint",
+					"user: ", user.Username,
+					"verb: ", verb,
+					"subresource: ", subresource)
+				http.Error(w, "Forbidden: User may not "+verb+" "+AdminResource+"/"+subresource, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+func TestAdminMiddlewareReviewsAccess(t *testing.T) {
+	var reviewed []authorizationv1.ResourceAttributes
+	var reviewErr error
+	previous := reviewAccess
+	reviewAccess = func(_ context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) (bool, error) {
+		reviewed = append(reviewed, attributes)
+		return user.Username == "admin" && attributes.Verb != "delete", reviewErr
+	}
+	t.Cleanup(func() { reviewAccess = previous })
+
+	router := mux.NewRouter()
+	adminroute := router.PathPrefix("/admin/cache").Subrouter()
+	adminroute.Use(AdminMiddleware("cache"))
+	adminroute.HandleFunc("/entry", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("GET", "POST", "DELETE")
+
+	serve := func(method, username string) int {
+		r := httptest.NewRequest(method, "/admin/cache/entry", nil)
+		if username != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey{}, authenticationv1.UserInfo{Username: username}))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		username string
+		status   int
+	}{
+		{"not authenticated", http.MethodGet, "", http.StatusUnauthorized},
+		{"allowed read", http.MethodGet, "admin", http.StatusNoContent},
+		{"allowed update", http.MethodPost, "admin", http.StatusNoContent},
+		{"denied verb", http.MethodDelete, "admin", http.StatusForbidden},
+		{"denied user", http.MethodGet, "viewer", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := serve(tt.method, tt.username); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	want := authorizationv1.ResourceAttributes{Group: AdminGroup, Resource: AdminResource, Subresource: "cache", Verb: "update"}
+	if len(reviewed) != 4 || reviewed[1] != want {
+		t.Errorf("reviewed = %+v, want the update reviewed as %+v", reviewed, want)
+	}
+
+	reviewErr = errors.New("API server unavailable")
+	if status := serve(http.MethodGet, "admin"); status != http.StatusInternalServerError {
+		t.Errorf("status when the review fails = %d, want %d", status, http.StatusInternalServerError)
+	}
+}
@@ -11,7 +11,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// AuthMiddleware is a middleware that validates Kubernetes tokens.
+// userContextKey is the request context key of the user a token was issued to
+type userContextKey struct{}
+
+// AuthMiddleware is a middleware that validates Kubernetes tokens. The user the token was issued to
+// is added to the request context for the authorization middlewares.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := extractToken(r)
@@ -20,12 +24,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if !validateKubernetesToken(token) {
+		user, ok := validateKubernetesToken(token)
+		if !ok {
 			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
 	})
 }
 
@@ -38,8 +43,14 @@ func extractToken(r *http.Request) string {
 	return ""
 }
 
-// validateKubernetesToken validates the token using the Kubernetes API.
-func validateKubernetesToken(token string) bool {
+// userFromContext returns the user AuthMiddleware authenticated the request as
+func userFromContext(ctx context.Context) (authenticationv1.UserInfo, bool) {
+	user, ok := ctx.Value(userContextKey{}).(authenticationv1.UserInfo)
+	return user, ok
+}
+
+// validateKubernetesToken validates the token using the Kubernetes API and returns the user it was issued to.
+func validateKubernetesToken(token string) (authenticationv1.UserInfo, bool) {
 	clientset := k8sclient.Kubernetes
 
 	// Create a TokenReview request
@@ -53,12 +64,12 @@ func validateKubernetesToken(token string) bool {
 	result, err := clientset.AuthenticationV1().TokenReviews().Create(context.TODO(), tokenReview, metav1.CreateOptions{})
 	if err != nil {
 		fmt.Printf("Error creating TokenReview: %v\n", err)
-		return false
+		return authenticationv1.UserInfo{}, false
 	}
 
 	// Check if the token is valid
 	if result.Status.Authenticated {
-		return true
+		return result.Status.User, true
 	}
-	return false
+	return authenticationv1.UserInfo{}, false
 }
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddlewareLabelsRequestsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware)
+	router.HandleFunc("/v1/machineproviders/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/v1/machineproviders/{uid}", "GET", "404"))
+	for _, uid := range []string{"a", "b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/machineproviders/"+uid, nil))
+	}
+
+	after := testutil.ToFloat64(httpRequests.WithLabelValues("/v1/machineproviders/{uid}", "GET", "404"))
+	if after-before != 2 {
+		t.Fatalf("expected 2 requests counted on the route template, got %v", after-before)
+	}
+}
@@ -2,12 +2,15 @@ package kubernetesproviderrepository
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/internal/helpers/unstructuredhelpers"
 	"github.com/vitistack/vitistack-operator/internal/repositoryinterfaces"
 
 	"github.com/vitistack/common/pkg/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // KubernetesProviderRepository interface defines operations for Kubernetes providers
@@ -16,102 +19,82 @@ type KubernetesProviderRepository interface {
 	repositoryinterfaces.Repository[v1alpha1.KubernetesProvider]
 }
 
-// KubernetesProviderRepositoryImpl implements KubernetesProviderRepository
+// kubernetesProviderGVR is read from the informer store when its watcher has synced
+var kubernetesProviderGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "kubernetesproviders"}
+
+// KubernetesProviderRepositoryImpl implements KubernetesProviderRepository.
+// Objects are read straight from the informer store, falling back to the cache
+// while the kubernetesproviders informer is not running or has not synced.
+// Objects from a store last confirmed longer ago than the cache's maximum staleness are refused
+// with cache.ErrStale, the same as stale cached objects.
 type KubernetesProviderRepositoryImpl struct {
 }
 
+// NewKubernetesProviderRepository returns the repository and registers the converter the cache uses to
+// store KubernetesProvider objects typed
 func NewKubernetesProviderRepository() KubernetesProviderRepository {
+	cache.RegisterConverter("KubernetesProvider", cache.TypedConverter[v1alpha1.KubernetesProvider]())
 	return &KubernetesProviderRepositoryImpl{}
 }
 
 // GetByUID implements Repository.GetByUID
 func (m *KubernetesProviderRepositoryImpl) GetByUID(ctx context.Context, uid string) (v1alpha1.KubernetesProvider, error) {
-	stringvalue, err := cache.Cache.Get(ctx, uid)
-	if err != nil {
-		return v1alpha1.KubernetesProvider{}, err
-	}
-
-	// Check if the string value is empty
-	if stringvalue == "" {
-		return v1alpha1.KubernetesProvider{}, nil
+	if lister, ok := dynamicclienthandler.GetLister(kubernetesProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(kubernetesProviderGVR); err != nil {
+			return v1alpha1.KubernetesProvider{}, err
+		}
+		obj, err := lister.GetByUID(uid)
+		if err != nil || obj == nil || obj.GetKind() != "KubernetesProvider" {
+			return v1alpha1.KubernetesProvider{}, err
+		}
+		return unstructuredhelpers.Convert[v1alpha1.KubernetesProvider](obj)
 	}
 
-	var KubernetesProvider v1alpha1.KubernetesProvider
-	err = json.Unmarshal([]byte(stringvalue), &KubernetesProvider)
-	if err != nil {
+	KubernetesProvider, found, err := cache.GetAsByUID[v1alpha1.KubernetesProvider](ctx, cache.Cache, uid)
+	if err != nil || !found || KubernetesProvider.Kind != "KubernetesProvider" {
 		return v1alpha1.KubernetesProvider{}, err
 	}
 
-	if KubernetesProvider.Kind != "KubernetesProvider" {
-		return v1alpha1.KubernetesProvider{}, nil
-	}
-
 	return KubernetesProvider, nil
 }
 
 // GetAll implements Repository.GetAll
 func (m *KubernetesProviderRepositoryImpl) GetAll(ctx context.Context) ([]v1alpha1.KubernetesProvider, error) {
-	KubernetesProviderIds, err := cache.Cache.Keys(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(KubernetesProviderIds) == 0 {
-		return nil, nil
-	}
-
-	KubernetesProviders := make([]v1alpha1.KubernetesProvider, 0)
-	for _, KubernetesProviderId := range KubernetesProviderIds {
-		KubernetesProviderString, err := cache.Cache.Get(ctx, KubernetesProviderId)
-		if err != nil {
-			continue
+	if lister, ok := dynamicclienthandler.GetLister(kubernetesProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(kubernetesProviderGVR); err != nil {
+			return nil, err
 		}
-
-		var KubernetesProvider v1alpha1.KubernetesProvider
-		err = json.Unmarshal([]byte(KubernetesProviderString), &KubernetesProvider)
+		objects, err := lister.List(labels.Everything())
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		if KubernetesProvider.Kind != "KubernetesProvider" {
-			continue
-		}
-
-		KubernetesProviders = append(KubernetesProviders, KubernetesProvider)
+		return unstructuredhelpers.ConvertAll[v1alpha1.KubernetesProvider](objects, "KubernetesProvider")
 	}
-	return KubernetesProviders, nil
+
+	return cache.ListAs[v1alpha1.KubernetesProvider](ctx, cache.Cache, cache.Query{Kind: "KubernetesProvider"})
 }
 
 // GetByName implements Repository.GetByName
 func (m *KubernetesProviderRepositoryImpl) GetByName(ctx context.Context, name string) (v1alpha1.KubernetesProvider, error) {
-	KubernetesProviderIds, err := cache.Cache.Keys(ctx)
-	if err != nil {
-		return v1alpha1.KubernetesProvider{}, err
-	}
-
-	if len(KubernetesProviderIds) == 0 {
-		return v1alpha1.KubernetesProvider{}, nil
-	}
-
-	for _, KubernetesProviderId := range KubernetesProviderIds {
-		KubernetesProviderString, err := cache.Cache.Get(ctx, KubernetesProviderId)
-		if err != nil {
-			continue
+	if lister, ok := dynamicclienthandler.GetLister(kubernetesProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(kubernetesProviderGVR); err != nil {
+			return v1alpha1.KubernetesProvider{}, err
 		}
-
-		var KubernetesProvider v1alpha1.KubernetesProvider
-		err = json.Unmarshal([]byte(KubernetesProviderString), &KubernetesProvider)
+		objects, err := lister.List(labels.Everything())
 		if err != nil {
-			continue
+			return v1alpha1.KubernetesProvider{}, err
 		}
-
-		if KubernetesProvider.Kind != "KubernetesProvider" {
-			continue
+		for _, obj := range objects {
+			if obj.GetName() == name && obj.GetKind() == "KubernetesProvider" {
+				return unstructuredhelpers.Convert[v1alpha1.KubernetesProvider](obj)
+			}
 		}
+		return v1alpha1.KubernetesProvider{}, nil
+	}
 
-		if KubernetesProvider.Name == name {
-			return KubernetesProvider, nil
-		}
+	KubernetesProviders, err := cache.ListAs[v1alpha1.KubernetesProvider](ctx, cache.Cache, cache.Query{Kind: "KubernetesProvider", Name: name})
+	if err != nil || len(KubernetesProviders) == 0 {
+		return v1alpha1.KubernetesProvider{}, err
 	}
-	return v1alpha1.KubernetesProvider{}, nil
+	return KubernetesProviders[0], nil
 }
@@ -2,12 +2,15 @@ package machineproviderrepository
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/internal/helpers/unstructuredhelpers"
 	"github.com/vitistack/vitistack-operator/internal/repositoryinterfaces"
 
 	"github.com/vitistack/common/pkg/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // MachineProviderRepository interface defines operations for Machine providers
@@ -16,102 +19,82 @@ type MachineProviderRepository interface {
 	repositoryinterfaces.Repository[v1alpha1.MachineProvider]
 }
 
-// MachineProviderRepositoryImpl implements MachineProviderRepository
+// machineProviderGVR is read from the informer store when its watcher has synced
+var machineProviderGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machineproviders"}
+
+// MachineProviderRepositoryImpl implements MachineProviderRepository.
+// Objects are read straight from the informer store, falling back to the cache
+// while the machineproviders informer is not running or has not synced.
+// Objects from a store last confirmed longer ago than the cache's maximum staleness are refused
+// with cache.ErrStale, the same as stale cached objects.
 type MachineProviderRepositoryImpl struct {
 }
 
+// NewMachineProviderRepository returns the repository and registers the converter the cache uses to
+// store MachineProvider objects typed
 func NewMachineProviderRepository() MachineProviderRepository {
+	cache.RegisterConverter("MachineProvider", cache.TypedConverter[v1alpha1.MachineProvider]())
 	return &MachineProviderRepositoryImpl{}
 }
 
 // GetByUID implements Repository.GetByUID
 func (m *MachineProviderRepositoryImpl) GetByUID(ctx context.Context, uid string) (v1alpha1.MachineProvider, error) {
-	stringvalue, err := cache.Cache.Get(ctx, uid)
-	if err != nil {
-		return v1alpha1.MachineProvider{}, err
-	}
-
-	// Check if the string value is empty
-	if stringvalue == "" {
-		return v1alpha1.MachineProvider{}, nil
+	if lister, ok := dynamicclienthandler.GetLister(machineProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineProviderGVR); err != nil {
+			return v1alpha1.MachineProvider{}, err
+		}
+		obj, err := lister.GetByUID(uid)
+		if err != nil || obj == nil || obj.GetKind() != "MachineProvider" {
+			return v1alpha1.MachineProvider{}, err
+		}
+		return unstructuredhelpers.Convert[v1alpha1.MachineProvider](obj)
 	}
 
-	var machineProvider v1alpha1.MachineProvider
-	err = json.Unmarshal([]byte(stringvalue), &machineProvider)
-	if err != nil {
+	machineProvider, found, err := cache.GetAsByUID[v1alpha1.MachineProvider](ctx, cache.Cache, uid)
+	if err != nil || !found || machineProvider.Kind != "MachineProvider" {
 		return v1alpha1.MachineProvider{}, err
 	}
 
-	if machineProvider.Kind != "MachineProvider" {
-		return v1alpha1.MachineProvider{}, nil
-	}
-
 	return machineProvider, nil
 }
 
 // GetAll implements Repository.GetAll
 func (m *MachineProviderRepositoryImpl) GetAll(ctx context.Context) ([]v1alpha1.MachineProvider, error) {
-	machineProviderIds, err := cache.Cache.Keys(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(machineProviderIds) == 0 {
-		return nil, nil
-	}
-
-	machineProviders := make([]v1alpha1.MachineProvider, 0)
-	for _, machineProviderId := range machineProviderIds {
-		machineProviderString, err := cache.Cache.Get(ctx, machineProviderId)
-		if err != nil {
-			continue
+	if lister, ok := dynamicclienthandler.GetLister(machineProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineProviderGVR); err != nil {
+			return nil, err
 		}
-
-		var machineProvider v1alpha1.MachineProvider
-		err = json.Unmarshal([]byte(machineProviderString), &machineProvider)
+		objects, err := lister.List(labels.Everything())
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		if machineProvider.Kind != "MachineProvider" {
-			continue
-		}
-
-		machineProviders = append(machineProviders, machineProvider)
+		return unstructuredhelpers.ConvertAll[v1alpha1.MachineProvider](objects, "MachineProvider")
 	}
-	return machineProviders, nil
+
+	return cache.ListAs[v1alpha1.MachineProvider](ctx, cache.Cache, cache.Query{Kind: "MachineProvider"})
 }
 
 // GetByName implements Repository.GetByName
 func (m *MachineProviderRepositoryImpl) GetByName(ctx context.Context, name string) (v1alpha1.MachineProvider, error) {
-	machineProviderIds, err := cache.Cache.Keys(ctx)
-	if err != nil {
-		return v1alpha1.MachineProvider{}, err
-	}
-
-	if len(machineProviderIds) == 0 {
-		return v1alpha1.MachineProvider{}, nil
-	}
-
-	for _, machineProviderId := range machineProviderIds {
-		machineProviderString, err := cache.Cache.Get(ctx, machineProviderId)
-		if err != nil {
-			continue
+	if lister, ok := dynamicclienthandler.GetLister(machineProviderGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineProviderGVR); err != nil {
+			return v1alpha1.MachineProvider{}, err
 		}
-
-		var machineProvider v1alpha1.MachineProvider
-		err = json.Unmarshal([]byte(machineProviderString), &machineProvider)
+		objects, err := lister.List(labels.Everything())
 		if err != nil {
-			continue
+			return v1alpha1.MachineProvider{}, err
 		}
-
-		if machineProvider.Kind != "MachineProvider" {
-			continue
+		for _, obj := range objects {
+			if obj.GetName() == name && obj.GetKind() == "MachineProvider" {
+				return unstructuredhelpers.Convert[v1alpha1.MachineProvider](obj)
+			}
 		}
+		return v1alpha1.MachineProvider{}, nil
+	}
 
-		if machineProvider.Name == name {
-			return machineProvider, nil
-		}
+	machineProviders, err := cache.ListAs[v1alpha1.MachineProvider](ctx, cache.Cache, cache.Query{Kind: "MachineProvider", Name: name})
+	if err != nil || len(machineProviders) == 0 {
+		return v1alpha1.MachineProvider{}, err
 	}
-	return v1alpha1.MachineProvider{}, nil
+	return machineProviders[0], nil
 }
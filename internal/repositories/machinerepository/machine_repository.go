@@ -0,0 +1,189 @@
+package machinerepository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/internal/helpers/unstructuredhelpers"
+	"github.com/vitistack/vitistack-operator/internal/repositoryinterfaces"
+
+	"github.com/vitistack/common/pkg/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MachineFilter selects machines. Empty fields match every machine.
+type MachineFilter struct {
+	Namespace string
+	// Phase is matched against the status phase, ignoring case
+	Phase string
+	// Provider is matched against the provider in the spec or the status, ignoring case
+	Provider string
+	// Cluster is matched against the name and the ID of the owning cluster
+	Cluster string
+}
+
+// MachineRepository interface defines operations for Machines
+type MachineRepository interface {
+	// Repository interface methods
+	repositoryinterfaces.Repository[v1alpha1.Machine]
+
+	// GetByNamespacedName retrieves a machine by its namespace and name
+	GetByNamespacedName(ctx context.Context, namespace, name string) (v1alpha1.Machine, error)
+
+	// Find retrieves the machines matching the filter
+	Find(ctx context.Context, filter MachineFilter) ([]v1alpha1.Machine, error)
+}
+
+// machineGVR is read from the informer store when its watcher has synced
+var machineGVR = schema.GroupVersionResource{Group: "vitistack.io", Version: "v1alpha1", Resource: "machines"}
+
+// MachineRepositoryImpl implements MachineRepository.
+// Objects are read straight from the informer store, falling back to the cache
+// while the machines informer is not running or has not synced.
+// Objects from a store last confirmed longer ago than the cache's maximum staleness are refused
+// with cache.ErrStale, the same as stale cached objects.
+type MachineRepositoryImpl struct {
+}
+
+// NewMachineRepository returns the repository and registers the converter the cache uses to
+// store Machine objects typed
+func NewMachineRepository() MachineRepository {
+	cache.RegisterConverter("Machine", cache.TypedConverter[v1alpha1.Machine]())
+	return &MachineRepositoryImpl{}
+}
+
+// GetByUID implements Repository.GetByUID
+func (m *MachineRepositoryImpl) GetByUID(ctx context.Context, uid string) (v1alpha1.Machine, error) {
+	if lister, ok := dynamicclienthandler.GetLister(machineGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineGVR); err != nil {
+			return v1alpha1.Machine{}, err
+		}
+		obj, err := lister.GetByUID(uid)
+		if err != nil || obj == nil || obj.GetKind() != "Machine" {
+			return v1alpha1.Machine{}, err
+		}
+		return unstructuredhelpers.Convert[v1alpha1.Machine](obj)
+	}
+
+	machine, found, err := cache.GetAsByUID[v1alpha1.Machine](ctx, cache.Cache, uid)
+	if err != nil || !found || machine.Kind != "Machine" {
+		return v1alpha1.Machine{}, err
+	}
+
+	return machine, nil
+}
+
+// GetAll implements Repository.GetAll
+func (m *MachineRepositoryImpl) GetAll(ctx context.Context) ([]v1alpha1.Machine, error) {
+	return m.Find(ctx, MachineFilter{})
+}
+
+// GetByName implements Repository.GetByName. Machines with the same name in several namespaces
+// are ambiguous, the one in the first namespace in alphabetical order is returned.
+func (m *MachineRepositoryImpl) GetByName(ctx context.Context, name string) (v1alpha1.Machine, error) {
+	machines, err := m.Find(ctx, MachineFilter{})
+	if err != nil {
+		return v1alpha1.Machine{}, err
+	}
+	for _, machine := range machines {
+		if machine.Name == name {
+			return machine, nil
+		}
+	}
+	return v1alpha1.Machine{}, nil
+}
+
+// GetByNamespacedName implements MachineRepository.GetByNamespacedName
+func (m *MachineRepositoryImpl) GetByNamespacedName(ctx context.Context, namespace, name string) (v1alpha1.Machine, error) {
+	if lister, ok := dynamicclienthandler.GetLister(machineGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineGVR); err != nil {
+			return v1alpha1.Machine{}, err
+		}
+		obj, err := lister.Get(namespace, name)
+		if err != nil || obj == nil || obj.GetKind() != "Machine" {
+			return v1alpha1.Machine{}, err
+		}
+		return unstructuredhelpers.Convert[v1alpha1.Machine](obj)
+	}
+
+	machine, found, err := cache.GetAs[v1alpha1.Machine](ctx, cache.Cache, cache.ObjectKey(machineGVR, namespace, name))
+	if err != nil || !found || machine.Kind != "Machine" {
+		return v1alpha1.Machine{}, err
+	}
+	return machine, nil
+}
+
+// Find implements MachineRepository.Find. Machines are sorted by namespace and name.
+func (m *MachineRepositoryImpl) Find(ctx context.Context, filter MachineFilter) ([]v1alpha1.Machine, error) {
+	machines, err := m.list(ctx, filter.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]v1alpha1.Machine, 0, len(machines))
+	for _, machine := range machines {
+		if filter.matches(machine) {
+			matching = append(matching, machine)
+		}
+	}
+	slices.SortFunc(matching, func(a, b v1alpha1.Machine) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return matching, nil
+}
+
+// list returns the machines in the namespace, or in every namespace if it is empty
+func (m *MachineRepositoryImpl) list(ctx context.Context, namespace string) ([]v1alpha1.Machine, error) {
+	if lister, ok := dynamicclienthandler.GetLister(machineGVR); ok {
+		if err := cache.Cache.CheckResourceFresh(machineGVR); err != nil {
+			return nil, err
+		}
+		objects, err := lister.ListNamespace(namespace, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		return unstructuredhelpers.ConvertAll[v1alpha1.Machine](objects, "Machine")
+	}
+
+	return cache.ListAs[v1alpha1.Machine](ctx, cache.Cache, cache.Query{Resource: machineGVR, Kind: "Machine", Namespace: namespace})
+}
+
+func (f MachineFilter) matches(machine v1alpha1.Machine) bool {
+	if f.Phase != "" && !strings.EqualFold(machine.Status.Phase, f.Phase) {
+		return false
+	}
+	if f.Provider != "" &&
+		!strings.EqualFold(string(machine.Spec.Provider), f.Provider) &&
+		!strings.EqualFold(string(machine.Status.Provider), f.Provider) {
+		return false
+	}
+	if f.Cluster != "" && !slices.Contains(owningCluster(machine), f.Cluster) {
+		return false
+	}
+	return true
+}
+
+// owningCluster returns the names and IDs the machine's owning cluster is known by: the name of
+// its KubernetesCluster owner and the cluster name and ID in its labels and annotations
+func owningCluster(machine v1alpha1.Machine) []string {
+	identifiers := make([]string, 0)
+	for _, owner := range machine.OwnerReferences {
+		if owner.Kind == "KubernetesCluster" {
+			identifiers = append(identifiers, owner.Name)
+		}
+	}
+	for _, key := range []string{v1alpha1.ClusterNameAnnotation, v1alpha1.ClusterIdAnnotation} {
+		if value := machine.Annotations[key]; value != "" {
+			identifiers = append(identifiers, value)
+		}
+		if value := machine.Labels[key]; value != "" {
+			identifiers = append(identifiers, value)
+		}
+	}
+	return identifiers
+}
@@ -0,0 +1,110 @@
+package machinerepository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vitistack/common/pkg/v1alpha1"
+	"github.com/vitistack/vitistack-operator/internal/cache"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestMachine(namespace, name, phase, provider string, metadata map[string]any) *unstructured.Unstructured {
+	metadata["namespace"] = namespace
+	metadata["name"] = name
+	metadata["uid"] = "uid-" + namespace + "-" + name
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "vitistack.io/v1alpha1",
+		"kind":       "Machine",
+		"metadata":   metadata,
+		"spec":       map[string]any{"provider": provider},
+		"status":     map[string]any{"phase": phase},
+	}}
+}
+
+func setupMachines(t *testing.T) MachineRepository {
+	t.Helper()
+	cache.Cache = cache.NewMockVitistackCache()
+	repository := NewMachineRepository()
+
+	machines := []*unstructured.Unstructured{
+		newTestMachine("team-a", "worker-1", v1alpha1.MachinePhaseRunning, "kubevirt", map[string]any{
+			"ownerReferences": []any{map[string]any{"apiVersion": "vitistack.io/v1alpha1", "kind": "KubernetesCluster", "name": "cluster-a", "uid": "uid-cluster-a"}},
+		}),
+		newTestMachine("team-a", "worker-2", v1alpha1.MachinePhaseFailed, "kubevirt", map[string]any{
+			"annotations": map[string]any{v1alpha1.ClusterNameAnnotation: "cluster-a"},
+		}),
+		newTestMachine("team-b", "worker-1", v1alpha1.MachinePhaseRunning, "proxmox", map[string]any{
+			"labels": map[string]any{v1alpha1.ClusterIdAnnotation: "cluster-b-id"},
+		}),
+	}
+	for _, machine := range machines {
+		key := cache.ObjectKey(machineGVR, machine.GetNamespace(), machine.GetName())
+		meta := cache.ObjectMeta{UID: string(machine.GetUID()), Kind: "Machine", Labels: machine.GetLabels()}
+		if err := cache.Cache.SetObject(context.Background(), key, meta, machine); err != nil {
+			t.Fatalf("SetObject failed: %v", err)
+		}
+	}
+	return repository
+}
+
+func TestFind(t *testing.T) {
+	repository := setupMachines(t)
+
+	tests := []struct {
+		name   string
+		filter MachineFilter
+		want   []string
+	}{
+		{"all", MachineFilter{}, []string{"team-a/worker-1", "team-a/worker-2", "team-b/worker-1"}},
+		{"namespace", MachineFilter{Namespace: "team-b"}, []string{"team-b/worker-1"}},
+		{"phase", MachineFilter{Phase: "running"}, []string{"team-a/worker-1", "team-b/worker-1"}},
+		{"provider", MachineFilter{Provider: "KubeVirt"}, []string{"team-a/worker-1", "team-a/worker-2"}},
+		{"cluster by owner and annotation", MachineFilter{Cluster: "cluster-a"}, []string{"team-a/worker-1", "team-a/worker-2"}},
+		{"cluster by ID", MachineFilter{Cluster: "cluster-b-id"}, []string{"team-b/worker-1"}},
+		{"combined", MachineFilter{Phase: "Running", Cluster: "cluster-a"}, []string{"team-a/worker-1"}},
+		{"no match", MachineFilter{Provider: "vsphere"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machines, err := repository.Find(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			got := make([]string, 0, len(machines))
+			for _, machine := range machines {
+				got = append(got, machine.Namespace+"/"+machine.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Find = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Find = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGetMachine(t *testing.T) {
+	ctx := context.Background()
+	repository := setupMachines(t)
+
+	machine, err := repository.GetByNamespacedName(ctx, "team-b", "worker-1")
+	if err != nil || machine.UID != "uid-team-b-worker-1" {
+		t.Errorf("GetByNamespacedName = %v, %v", machine.UID, err)
+	}
+	if machine, err := repository.GetByNamespacedName(ctx, "team-b", "worker-2"); err != nil || machine.Name != "" {
+		t.Errorf("GetByNamespacedName of a missing machine = %v, %v", machine.Name, err)
+	}
+
+	if machine, err := repository.GetByUID(ctx, "uid-team-a-worker-2"); err != nil || machine.Name != "worker-2" {
+		t.Errorf("GetByUID = %v, %v", machine.Name, err)
+	}
+
+	if machine, err := repository.GetByName(ctx, "worker-1"); err != nil || machine.Namespace != "team-a" {
+		t.Errorf("GetByName should return the machine in the first namespace, got %v, %v", machine.Namespace, err)
+	}
+}
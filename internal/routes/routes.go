@@ -2,28 +2,67 @@ package routes
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vitistack/vitistack-operator/internal/handlers/cachehandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/deadletterhandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/eventhandler"
 	"github.com/vitistack/vitistack-operator/internal/handlers/healthhandler"
 	"github.com/vitistack/vitistack-operator/internal/handlers/kubernetesprovidershandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/leaderhandler"
 	"github.com/vitistack/vitistack-operator/internal/handlers/machineprovidershandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/machineshandler"
 	"github.com/vitistack/vitistack-operator/internal/handlers/versionhandler"
 	"github.com/vitistack/vitistack-operator/internal/handlers/vitistackhandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/watcherhandler"
+	"github.com/vitistack/vitistack-operator/internal/handlers/writerhandler"
 	"github.com/vitistack/vitistack-operator/internal/middlewares"
 )
 
 func SetupRoutes(r *mux.Router) {
 	r.Use(middlewares.LoggingMiddleware)
+	r.Use(middlewares.MetricsMiddleware)
 	r.Use(middlewares.ContentTypeMiddleware) // Add ContentTypeMiddleware for all routes
 
 	r.HandleFunc("/health", healthhandler.HealthCheck).Methods("GET")
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
 	r.HandleFunc("/v1/info/version", versionhandler.GetVersion).Methods("GET")
+	r.HandleFunc("/v1/info/writequeue", writerhandler.GetQueueStats).Methods("GET")
+	r.HandleFunc("/v1/info/leader", leaderhandler.GetLeader).Methods("GET")
+	r.HandleFunc("/v1/info/watchers", watcherhandler.GetWatchers).Methods("GET")
+	r.HandleFunc("/v1/info/subscriptions", eventhandler.GetSubscriptions).Methods("GET")
+	r.HandleFunc("/v1/info/cache", cachehandler.GetFreshness).Methods("GET")
 
 	v1route := r.NewRoute().Subrouter().PathPrefix("/v1").Subrouter()
 	v1route.Use(middlewares.AuthMiddleware)
 	v1route.HandleFunc("/vitistack/name", vitistackhandler.GetName).Methods("GET")
+	v1route.HandleFunc("/events", eventhandler.GetEvents).Methods("GET")
+
+	// The dead letter admin endpoints expose failed events and redrive or drop them, so besides a
+	// valid token the user needs RBAC access to the operatoradmin/deadletters subresource
+	deadletterroute := v1route.PathPrefix("/admin/deadletters").Subrouter()
+	deadletterroute.Use(middlewares.AdminMiddleware("deadletters"))
+	deadletterroute.HandleFunc("", deadletterhandler.GetDeadLetters).Methods("GET")
+	deadletterroute.HandleFunc("/redrive", deadletterhandler.RedriveDeadLetters).Methods("POST")
+	deadletterroute.HandleFunc("/{id}/redrive", deadletterhandler.RedriveDeadLetter).Methods("POST")
+	deadletterroute.HandleFunc("/{id}", deadletterhandler.DeleteDeadLetter).Methods("DELETE")
+
+	// The cache admin endpoints expose raw objects and change the operator's state, so besides a
+	// valid token the user needs RBAC access to the operatoradmin/cache subresource
+	cacheroute := v1route.PathPrefix("/admin/cache").Subrouter()
+	cacheroute.Use(middlewares.AdminMiddleware("cache"))
+	cacheroute.HandleFunc("/stats", cachehandler.GetStats).Methods("GET")
+	cacheroute.HandleFunc("/keys", cachehandler.GetKeys).Methods("GET")
+	cacheroute.HandleFunc("/entry", cachehandler.GetEntry).Methods("GET")
+	cacheroute.HandleFunc("/entry", cachehandler.EvictEntry).Methods("DELETE")
+	cacheroute.HandleFunc("/entry/refresh", cachehandler.RefreshEntry).Methods("POST")
 
 	v1route.HandleFunc("/machineproviders", machineprovidershandler.GetMachineProviders).Methods("GET")
 	v1route.HandleFunc("/machineproviders/{uid}", machineprovidershandler.GetMachineProviderByUID).Methods("GET")
 
+	v1route.HandleFunc("/machines", machineshandler.GetMachines).Methods("GET")
+	v1route.HandleFunc("/machines/{uid}", machineshandler.GetMachineByUID).Methods("GET")
+	v1route.HandleFunc("/machines/{namespace}/{name}", machineshandler.GetMachineByNamespacedName).Methods("GET")
+
 	v1route.HandleFunc("/kubernetesproviders", kubernetesprovidershandler.GetKubernetesProviders).Methods("GET")
 	v1route.HandleFunc("/kubernetesproviders/{uid}", kubernetesprovidershandler.GetKubernetesProviderByUID).Methods("GET")
 }
@@ -2,25 +2,27 @@ package dynamichandler
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/vitistack/common/pkg/loggers/vlog"
 	localcache "github.com/vitistack/vitistack-operator/internal/cache"
 	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
 	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
 )
 
 type handler struct {
+	bus *eventmanager.EventManager
 }
 
-func NewDynamicClientHandler() dynamicclienthandler.DynamicClientHandler {
-	ret := handler{}
+// NewDynamicClientHandler returns a handler that caches watched objects and publishes their events to bus
+func NewDynamicClientHandler(bus *eventmanager.EventManager) dynamicclienthandler.DynamicClientHandler {
+	ret := handler{bus: bus}
 	return &ret
 }
 
-func (handler) AddResource(obj any) {
+func (h handler) AddResource(resource schema.GroupVersionResource, obj any) {
 	if obj == nil {
 		vlog.Error("AddResource called with nil object", nil)
 		return
@@ -31,29 +33,20 @@ func (handler) AddResource(obj any) {
 		return
 	}
 
-	// Determine cache key based on resource kind
-	cacheKey := string(unstructuredObject.GetUID())
-	if unstructuredObject.GetKind() == "ConfigMap" {
-		// For ConfigMaps, use the name as the key
-		namespace := unstructuredObject.GetNamespace()
-		name := unstructuredObject.GetName()
-		cacheKey = fmt.Sprintf("configmap-%s-%s", namespace, name)
-	}
-
-	err := localcache.Cache.Set(context.TODO(), cacheKey, unstructuredObject.Object)
+	err := localcache.Cache.SetObject(context.TODO(), objectKey(resource, unstructuredObject), objectMeta(unstructuredObject), unstructuredObject)
 	if err != nil {
 		vlog.Error("Error setting cache:", err)
 		return
 	}
 
 	// Publish event to notify subscribers
-	eventmanager.EventBus.Publish(eventmanager.ResourceEvent{
+	h.bus.Publish(eventmanager.ResourceEvent{
 		Type:     eventmanager.EventAdd,
 		Resource: unstructuredObject,
 	})
 }
 
-func (handler) DeleteResource(obj any) {
+func (h handler) DeleteResource(resource schema.GroupVersionResource, obj any) {
 	if obj == nil {
 		vlog.Error("DeleteResource called with nil object", nil)
 		return
@@ -76,29 +69,21 @@ func (handler) DeleteResource(obj any) {
 		}
 	}
 
-	// Determine cache key based on resource kind
-	cacheKey := string(unstructuredObject.GetUID())
-	if unstructuredObject.GetKind() == "ConfigMap" {
-		// For ConfigMaps, use the name as the key
-		namespace := unstructuredObject.GetNamespace()
-		name := unstructuredObject.GetName()
-		cacheKey = fmt.Sprintf("configmap-%s-%s", namespace, name)
-	}
-
-	err := localcache.Cache.Delete(context.TODO(), cacheKey)
+	// The object is gone from the cluster even if the cache entry could not be removed,
+	// so subscribers are always told about the delete
+	err := localcache.Cache.DeleteObject(context.TODO(), objectKey(resource, unstructuredObject))
 	if err != nil {
 		vlog.Error("Error deleting cache:", err)
-		return
 	}
 
 	// Publish event to notify subscribers
-	eventmanager.EventBus.Publish(eventmanager.ResourceEvent{
+	h.bus.Publish(eventmanager.ResourceEvent{
 		Type:     eventmanager.EventDelete,
 		Resource: unstructuredObject,
 	})
 }
 
-func (handler) UpdateResource(_ any, obj any) {
+func (h handler) UpdateResource(resource schema.GroupVersionResource, oldObj any, obj any) {
 	if obj == nil {
 		vlog.Error("UpdateResource called with nil object", nil)
 		return
@@ -109,24 +94,47 @@ func (handler) UpdateResource(_ any, obj any) {
 		return
 	}
 
-	// Determine cache key based on resource kind
-	cacheKey := string(unstructuredObject.GetUID())
-	if unstructuredObject.GetKind() == "ConfigMap" {
-		// For ConfigMaps, use the name as the key
-		namespace := unstructuredObject.GetNamespace()
-		name := unstructuredObject.GetName()
-		cacheKey = fmt.Sprintf("configmap-%s-%s", namespace, name)
-	}
-
-	err := localcache.Cache.Set(context.TODO(), cacheKey, unstructuredObject.Object)
+	err := localcache.Cache.SetObject(context.TODO(), objectKey(resource, unstructuredObject), objectMeta(unstructuredObject), unstructuredObject)
 	if err != nil {
 		vlog.Error("Error updating cache:", err)
 		return
 	}
 
+	// The old object lets subscribers see which fields changed; the event is still published without it
+	oldUnstructuredObject, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		oldUnstructuredObject = nil
+	}
+
 	// Publish event to notify subscribers
-	eventmanager.EventBus.Publish(eventmanager.ResourceEvent{
-		Type:     eventmanager.EventUpdate,
-		Resource: unstructuredObject,
+	h.bus.Publish(eventmanager.ResourceEvent{
+		Type:        eventmanager.EventUpdate,
+		Resource:    unstructuredObject,
+		OldResource: oldUnstructuredObject,
 	})
 }
+
+// ResourceSynced drops the objects of the resource that were loaded from a cache snapshot and are
+// no longer in the cluster, now that the watcher has delivered every object that still is
+func (h handler) ResourceSynced(resource schema.GroupVersionResource) {
+	dropped := localcache.Cache.DropRestored(context.TODO(), resource)
+	if dropped > 0 {
+		vlog.Info("Dropped cache entries restored from snapshot that no longer exist",
+			"resource: ", resource.String(),
+			"dropped: ", dropped)
+	}
+}
+
+// objectKey returns the cache key of a watched object
+func objectKey(resource schema.GroupVersionResource, obj *unstructured.Unstructured) localcache.Key {
+	return localcache.ObjectKey(resource, obj.GetNamespace(), obj.GetName())
+}
+
+// objectMeta returns the metadata the cache indexes a watched object by
+func objectMeta(obj *unstructured.Unstructured) localcache.ObjectMeta {
+	return localcache.ObjectMeta{
+		UID:    string(obj.GetUID()),
+		Kind:   obj.GetKind(),
+		Labels: obj.GetLabels(),
+	}
+}
@@ -0,0 +1,63 @@
+package dynamichandler
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	localcache "github.com/vitistack/vitistack-operator/internal/cache"
+	"github.com/vitistack/vitistack-operator/pkg/eventmanager"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDeleteResourcePublishesOnEveryReplicaSharingRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"namespace": "default", "name": "config", "uid": "uid-config"},
+	}}
+
+	previous := localcache.Cache
+	t.Cleanup(func() { localcache.Cache = previous })
+
+	// Each replica has its own cache on the shared server and its own event bus, and both watch
+	// the object. The cache is a global, so it is swapped in before each replica's handler runs.
+	type replica struct {
+		cache   *localcache.VitistackCache
+		handler handler
+		deletes *atomic.Int32
+	}
+	replicas := make([]replica, 2)
+	for i := range replicas {
+		c, err := localcache.VitistackCache{}.NewVitistackCache(
+			localcache.WithRedis(localcache.RedisOptions{Address: server.Addr()}),
+			localcache.WithKeyPrefix("vitistack-operator:"),
+		)
+		if err != nil {
+			t.Fatalf("NewVitistackCache failed: %v", err)
+		}
+		bus := eventmanager.NewEventManager()
+		deletes := &atomic.Int32{}
+		bus.SubscribeFiltered(eventmanager.Filter{Types: []eventmanager.EventType{eventmanager.EventDelete}}, func(eventmanager.ResourceEvent) {
+			deletes.Add(1)
+		})
+		replicas[i] = replica{cache: c, handler: handler{bus: bus}, deletes: deletes}
+
+		localcache.Cache = c
+		replicas[i].handler.AddResource(configMaps, configMap.DeepCopy())
+	}
+
+	for i, r := range replicas {
+		localcache.Cache = r.cache
+		r.handler.DeleteResource(configMaps, configMap.DeepCopy())
+		if deletes := r.deletes.Load(); deletes != 1 {
+			t.Errorf("replica %d published %d DELETE events, want 1", i, deletes)
+		}
+		if r.cache.Contains(localcache.ObjectKey(configMaps, "default", "config")) {
+			t.Errorf("replica %d still indexes the deleted object", i)
+		}
+	}
+}
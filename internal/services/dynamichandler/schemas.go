@@ -1,11 +1,19 @@
 package dynamichandler
 
 import (
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/internal/clients/dynamicclienthandler"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
 )
 
-func (handler) GetSchemas() []schema.GroupVersionResource {
-	return []schema.GroupVersionResource{
+// defaultSchemas returns the resources watched when WATCHED_RESOURCES is not set.
+// Only the operator's own ConfigMap is watched, not every ConfigMap in the cluster.
+func defaultSchemas() []dynamicclienthandler.WatchedResource {
+	return []dynamicclienthandler.WatchedResource{
 		{
 			Group:    "vitistack.io",
 			Version:  "v1alpha1",
@@ -32,9 +40,46 @@ func (handler) GetSchemas() []schema.GroupVersionResource {
 			Resource: "machines",
 		},
 		{
-			Group:    "",
-			Version:  "v1",
-			Resource: "configmaps",
+			Group:      "",
+			Version:    "v1",
+			Resource:   "configmaps",
+			Namespaces: []string{viper.GetString(consts.NAMESPACE)},
+			Name:       viper.GetString(consts.CONFIGMAPNAME),
 		},
 	}
 }
+
+// GetSchemas returns the resources to watch from the WATCHED_RESOURCES setting, a JSON list of
+// resources, or the default resources if it is not set. An invalid setting stops the operator.
+func (handler) GetSchemas() []dynamicclienthandler.WatchedResource {
+	schemas, err := parseWatchedResources(viper.GetString(consts.WATCHED_RESOURCES))
+	if err != nil {
+		vlog.Fatal("Invalid watched resources configuration", err)
+	}
+	return schemas
+}
+
+// parseWatchedResources parses and validates a JSON list of watched resources
+func parseWatchedResources(value string) ([]dynamicclienthandler.WatchedResource, error) {
+	if value == "" {
+		return defaultSchemas(), nil
+	}
+
+	var schemas []dynamicclienthandler.WatchedResource
+	if err := json.Unmarshal([]byte(value), &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", consts.WATCHED_RESOURCES, err)
+	}
+
+	seen := make(map[string]bool, len(schemas))
+	for i, schema := range schemas {
+		if err := schema.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s entry %d: %w", consts.WATCHED_RESOURCES, i, err)
+		}
+		gvr := schema.GroupVersionResource().String()
+		if seen[gvr] {
+			return nil, fmt.Errorf("invalid %s entry %d: %s is listed more than once, use namespaces to watch it in several namespaces", consts.WATCHED_RESOURCES, i, schema.Resource)
+		}
+		seen[gvr] = true
+	}
+	return schemas, nil
+}
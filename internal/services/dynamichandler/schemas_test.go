@@ -0,0 +1,37 @@
+package dynamichandler
+
+import "testing"
+
+func TestParseWatchedResources(t *testing.T) {
+	schemas, err := parseWatchedResources("")
+	if err != nil || len(schemas) != len(defaultSchemas()) {
+		t.Fatalf("expected default schemas for empty setting, got %v, %v", schemas, err)
+	}
+
+	schemas, err = parseWatchedResources(`[
+		{"group": "vitistack.io", "version": "v1alpha1", "resource": "machines", "namespaces": ["a", "b"], "labelSelector": "tier=prod"},
+		{"version": "v1", "resource": "configmaps", "fieldSelector": "metadata.name=vitistack-config"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schemas) != 2 || len(schemas[0].Namespaces) != 2 || schemas[0].LabelSelector != "tier=prod" {
+		t.Errorf("unexpected schemas: %+v", schemas)
+	}
+	if schemas[1].GroupVersionResource().String() != "/v1, Resource=configmaps" {
+		t.Errorf("unexpected configmaps resource: %s", schemas[1].GroupVersionResource())
+	}
+
+	invalid := map[string]string{
+		"malformed json":     `[{"resource": `,
+		"missing resource":   `[{"group": "vitistack.io", "version": "v1alpha1"}]`,
+		"invalid label":      `[{"version": "v1", "resource": "configmaps", "labelSelector": "a in (b"}]`,
+		"invalid field":      `[{"version": "v1", "resource": "configmaps", "fieldSelector": "metadata.name"}]`,
+		"duplicate resource": `[{"version": "v1", "resource": "configmaps"}, {"version": "v1", "resource": "configmaps"}]`,
+	}
+	for name, value := range invalid {
+		if _, err := parseWatchedResources(value); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package leaderelectionservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/vitistack/common/pkg/clients/k8sclient"
+	"github.com/vitistack/common/pkg/loggers/vlog"
+	"github.com/vitistack/vitistack-operator/pkg/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderInfo describes the current leader election state of this replica
+type LeaderInfo struct {
+	Enabled  bool   `json:"enabled"`
+	Identity string `json:"identity"`
+	Leader   string `json:"leader"`
+	IsLeader bool   `json:"isLeader"`
+	Lease    string `json:"lease,omitempty"`
+}
+
+// ErrShuttingDown is the cause of the cancellation of a lead call's context when the operator
+// shuts down while leading, as opposed to losing the lease
+var ErrShuttingDown = errors.New("operator is shutting down")
+
+var (
+	identity string
+	elector  *leaderelection.LeaderElector
+	mutex    sync.RWMutex
+)
+
+// Run calls lead with a context that is cancelled when this replica loses leadership, and keeps
+// competing for leadership again until ctx is cancelled. Only one call of lead runs at a time.
+// With leader election disabled, lead is called once and runs until ctx is cancelled.
+//
+// When ctx is cancelled, the lead context is cancelled with ErrShuttingDown as its cause, and the
+// lease is only released after lead has returned, so lead can finish its work while still leading.
+func Run(ctx context.Context, lead func(ctx context.Context)) error {
+	mutex.Lock()
+	identity = getIdentity()
+	mutex.Unlock()
+
+	if !viper.GetBool(consts.LEADER_ELECTION_ENABLED) {
+		vlog.Info("Leader election is disabled, running as leader",
+			"identity: ", identity)
+		leadCtx, cancel := leadContext(context.Background(), ctx)
+		defer cancel()
+		lead(leadCtx)
+		return nil
+	}
+
+	if k8sclient.Kubernetes == nil {
+		return errors.New("kubernetes client is not initialized")
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      viper.GetString(consts.LEADER_ELECTION_LEASE_NAME),
+			Namespace: viper.GetString(consts.NAMESPACE),
+		},
+		Client: k8sclient.Kubernetes.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	// leadMutex is held while lead runs. client-go starts OnStartedLeading in its own goroutine
+	// and does not wait for it, so this keeps lead calls from consecutive terms from overlapping.
+	var leadMutex sync.Mutex
+
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            lock.LeaseMeta.Name,
+		LeaseDuration:   viper.GetDuration(consts.LEADER_ELECTION_LEASE_DURATION),
+		RenewDeadline:   viper.GetDuration(consts.LEADER_ELECTION_RENEW_DEADLINE),
+		RetryPeriod:     viper.GetDuration(consts.LEADER_ELECTION_RETRY_PERIOD),
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				leadMutex.Lock()
+				defer leadMutex.Unlock()
+				if leaderCtx.Err() != nil || ctx.Err() != nil {
+					return
+				}
+				vlog.Info("Started leading",
+					"identity: ", identity,
+					"lease: ", lock.LeaseMeta.Name)
+				leadCtx, cancel := leadContext(leaderCtx, ctx)
+				defer cancel()
+				lead(leadCtx)
+			},
+			OnStoppedLeading: func() {
+				vlog.Info("Stopped leading",
+					"identity: ", identity,
+					"lease: ", lock.LeaseMeta.Name)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					vlog.Info("New leader elected",
+						"leader: ", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	mutex.Lock()
+	elector = le
+	mutex.Unlock()
+
+	// The elector gets its own context, so the lease is kept and renewed on shutdown until the
+	// current lead call has returned. Run returns when leadership is lost; compete again until then.
+	electorCtx, stopElector := context.WithCancel(context.Background())
+	electorDone := make(chan struct{})
+	go func() {
+		defer close(electorDone)
+		for electorCtx.Err() == nil {
+			le.Run(electorCtx)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// Wait for the last lead call to finish, then release the lease
+	leadMutex.Lock()
+	stopElector()
+	leadMutex.Unlock()
+	<-electorDone
+	return nil
+}
+
+// leadContext returns the context of a lead call. It is cancelled when leaderCtx is, on losing
+// the lease, or with ErrShuttingDown as its cause when shutdown is cancelled.
+func leadContext(leaderCtx, shutdown context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(leaderCtx)
+	stop := context.AfterFunc(shutdown, func() { cancel(ErrShuttingDown) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// GetLeader returns the leader election state of this replica
+func GetLeader() LeaderInfo {
+	mutex.RLock()
+	le, identity := elector, identity
+	mutex.RUnlock()
+
+	if !viper.GetBool(consts.LEADER_ELECTION_ENABLED) {
+		return LeaderInfo{
+			Identity: identity,
+			Leader:   identity,
+			IsLeader: true,
+		}
+	}
+
+	info := LeaderInfo{
+		Enabled:  true,
+		Identity: identity,
+		Lease:    fmt.Sprintf("%s/%s", viper.GetString(consts.NAMESPACE), viper.GetString(consts.LEADER_ELECTION_LEASE_NAME)),
+	}
+	if le != nil {
+		info.Leader = le.GetLeader()
+		info.IsLeader = le.IsLeader()
+	}
+	return info
+}
+
+// getIdentity returns the identity this replica uses in the lease, the pod name when running in a cluster
+func getIdentity() string {
+	if podName := viper.GetString(consts.POD_NAME); podName != "" {
+		return podName
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		vlog.Error("Failed to get hostname for leader election identity", err)
+		return "vitistack-operator"
+	}
+	return hostname
+}
@@ -0,0 +1,33 @@
+package leaderelectionservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLeadContextCause(t *testing.T) {
+	t.Run("shutdown", func(t *testing.T) {
+		shutdown, stop := context.WithCancel(context.Background())
+		ctx, cancel := leadContext(context.Background(), shutdown)
+		defer cancel()
+
+		stop()
+		<-ctx.Done()
+		if cause := context.Cause(ctx); !errors.Is(cause, ErrShuttingDown) {
+			t.Errorf("expected ErrShuttingDown on shutdown, got %v", cause)
+		}
+	})
+
+	t.Run("lost lease", func(t *testing.T) {
+		leaderCtx, loseLease := context.WithCancel(context.Background())
+		ctx, cancel := leadContext(leaderCtx, context.Background())
+		defer cancel()
+
+		loseLease()
+		<-ctx.Done()
+		if cause := context.Cause(ctx); errors.Is(cause, ErrShuttingDown) {
+			t.Errorf("expected losing the lease not to be reported as a shutdown, got %v", cause)
+		}
+	})
+}
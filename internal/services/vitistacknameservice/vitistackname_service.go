@@ -12,6 +12,7 @@ import (
 	"github.com/vitistack/vitistack-operator/pkg/consts"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // Required fields in the configmap that must be present
@@ -45,7 +46,8 @@ func GetName(ctx context.Context) (string, error) {
 	cacheKey := buildCacheKey(namespace, configMapName)
 	configMap, err := getConfigMapFromK8s(ctx, namespace, configMapName)
 	if err == nil && configMap != nil {
-		err = cache.Cache.Set(ctx, cacheKey, configMap)
+		meta := cache.ObjectMeta{UID: string(configMap.UID), Kind: "ConfigMap", Labels: configMap.Labels}
+		err = cache.Cache.SetObject(ctx, cacheKey, meta, configMap)
 		if err != nil {
 			vlog.Error("Failed to update cache with fresh ConfigMap data:", err)
 		} else {
@@ -59,7 +61,7 @@ func GetName(ctx context.Context) (string, error) {
 // InvalidateCache removes the ConfigMap from cache to force fresh data retrieval
 func InvalidateCache(ctx context.Context, namespace, name string) error {
 	cacheKey := buildCacheKey(namespace, name)
-	err := cache.Cache.Delete(ctx, cacheKey)
+	err := cache.Cache.DeleteObject(ctx, cacheKey)
 	if err != nil {
 		vlog.Error("Failed to invalidate cache:", err)
 		return err
@@ -68,9 +70,12 @@ func InvalidateCache(ctx context.Context, namespace, name string) error {
 	return nil
 }
 
-// buildCacheKey creates a standardized cache key for config maps
-func buildCacheKey(namespace, name string) string {
-	return fmt.Sprintf("configmap-%s-%s", namespace, name)
+// configMapsGVR is the resource the operator's ConfigMap is cached under
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// buildCacheKey returns the cache key of a config map, the same key the watcher caches it under
+func buildCacheKey(namespace, name string) cache.Key {
+	return cache.ObjectKey(configMapsGVR, namespace, name)
 }
 
 // extractConfigDataFromCache extracts config map data from the cached JSON string
@@ -121,7 +126,7 @@ func getConfigMapFromK8s(ctx context.Context, namespace, name string) (*corev1.C
 // getConfigDataFromCache attempts to retrieve config data from the cache
 func getConfigDataFromCache(ctx context.Context, namespace, name string) (map[string]string, error) {
 	cacheKey := buildCacheKey(namespace, name)
-	cachedDataStr, err := cache.Cache.Get(ctx, cacheKey)
+	cachedDataStr, err := cache.Cache.GetObject(ctx, cacheKey)
 	if err != nil {
 		vlog.Error("Cache miss:", err)
 		return nil, err
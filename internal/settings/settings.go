@@ -19,6 +19,34 @@ func Init() {
 	viper.SetDefault(consts.DEVELOPMENT, false)
 	viper.SetDefault(consts.LOG_JSON_LOGGING, true)
 	viper.SetDefault(consts.LOG_LEVEL, "info")
+	viper.SetDefault(consts.WRITER_RETRY_BASE_DELAY, "500ms")
+	viper.SetDefault(consts.WRITER_RETRY_MAX_DELAY, "5m")
+	viper.SetDefault(consts.WRITER_MAX_RETRIES, 0) // 0 = retry until the write succeeds
+	viper.SetDefault(consts.STATUS_FLUSH_INTERVAL, "5s")
+	viper.SetDefault(consts.STATUS_RECONCILE_INTERVAL, "10m") // 0 disables the periodic rebuild
+	viper.SetDefault(consts.DISCOVERY_POLL_INTERVAL, "30s")   // 0 only checks for resources at startup
+	viper.SetDefault(consts.INFORMER_RESYNC_PERIOD, "0")      // 0 disables periodic resync
+	viper.SetDefault(consts.LEADER_ELECTION_ENABLED, true)
+	viper.SetDefault(consts.LEADER_ELECTION_LEASE_NAME, "vitistack-operator")
+	viper.SetDefault(consts.LEADER_ELECTION_LEASE_DURATION, "15s")
+	viper.SetDefault(consts.LEADER_ELECTION_RENEW_DEADLINE, "10s")
+	viper.SetDefault(consts.LEADER_ELECTION_RETRY_PERIOD, "2s")
+	viper.SetDefault(consts.EVENT_HISTORY_SIZE, 1000) // 0 disables the event history
+	viper.SetDefault(consts.EVENT_QUEUE_SIZE, 1000)   // 0 invokes subscribers synchronously
+	viper.SetDefault(consts.EVENT_QUEUE_POLICY, "coalesce")
+	viper.SetDefault(consts.EVENT_RETRY_MAX_ATTEMPTS, 5)
+	viper.SetDefault(consts.EVENT_RETRY_BASE_DELAY, "500ms")
+	viper.SetDefault(consts.EVENT_RETRY_MAX_DELAY, "30s")
+	viper.SetDefault(consts.EVENT_DEAD_LETTER_SIZE, 1000) // 0 discards events that keep failing
+	viper.SetDefault(consts.CACHE_MODE, "json")           // json or object
+	viper.SetDefault(consts.CACHE_MAX_STALENESS, "0")     // 0 serves cached objects of any age
+	viper.SetDefault(consts.CACHE_BACKEND, "memory")      // memory or redis
+	viper.SetDefault(consts.CACHE_KEY_PREFIX, "vitistack-operator:")
+	viper.SetDefault(consts.CACHE_REDIS_ADDRESS, "")
+	viper.SetDefault(consts.CACHE_REDIS_PASSWORD, "")
+	viper.SetDefault(consts.CACHE_REDIS_DB, 0)
+	viper.SetDefault(consts.CACHE_SNAPSHOT_PATH, "") // empty disables snapshots
+	viper.SetDefault(consts.CACHE_SNAPSHOT_INTERVAL, "5m")
 
 	dotenv.LoadDotEnv()
 
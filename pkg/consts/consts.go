@@ -11,4 +11,46 @@ var (
 	VITISTACKCRDNAME        = "VITISTACKCRDNAME"
 	CONFIGMAPNAME           = "CONFIGMAPNAME"
 	NAMESPACE               = "NAMESPACE"
+
+	// Vitistack status writer
+	WRITER_RETRY_BASE_DELAY   = "WRITER_RETRY_BASE_DELAY"
+	WRITER_RETRY_MAX_DELAY    = "WRITER_RETRY_MAX_DELAY"
+	WRITER_MAX_RETRIES        = "WRITER_MAX_RETRIES"
+	STATUS_FLUSH_INTERVAL     = "STATUS_FLUSH_INTERVAL"
+	STATUS_RECONCILE_INTERVAL = "STATUS_RECONCILE_INTERVAL"
+
+	// Dynamic watchers
+	DISCOVERY_POLL_INTERVAL = "DISCOVERY_POLL_INTERVAL"
+	WATCHED_RESOURCES       = "WATCHED_RESOURCES"
+	INFORMER_RESYNC_PERIOD  = "INFORMER_RESYNC_PERIOD"
+
+	// Leader election
+	POD_NAME                       = "POD_NAME"
+	LEADER_ELECTION_ENABLED        = "LEADER_ELECTION_ENABLED"
+	LEADER_ELECTION_LEASE_NAME     = "LEADER_ELECTION_LEASE_NAME"
+	LEADER_ELECTION_LEASE_DURATION = "LEADER_ELECTION_LEASE_DURATION"
+	LEADER_ELECTION_RENEW_DEADLINE = "LEADER_ELECTION_RENEW_DEADLINE"
+	LEADER_ELECTION_RETRY_PERIOD   = "LEADER_ELECTION_RETRY_PERIOD"
+
+	// Event bus
+	EVENT_HISTORY_SIZE = "EVENT_HISTORY_SIZE"
+	EVENT_QUEUE_SIZE   = "EVENT_QUEUE_SIZE"
+	EVENT_QUEUE_POLICY = "EVENT_QUEUE_POLICY"
+
+	// Event retries and dead letters
+	EVENT_RETRY_MAX_ATTEMPTS = "EVENT_RETRY_MAX_ATTEMPTS"
+	EVENT_RETRY_BASE_DELAY   = "EVENT_RETRY_BASE_DELAY"
+	EVENT_RETRY_MAX_DELAY    = "EVENT_RETRY_MAX_DELAY"
+	EVENT_DEAD_LETTER_SIZE   = "EVENT_DEAD_LETTER_SIZE"
+
+	// Cache
+	CACHE_MODE              = "CACHE_MODE"
+	CACHE_MAX_STALENESS     = "CACHE_MAX_STALENESS"
+	CACHE_BACKEND           = "CACHE_BACKEND"
+	CACHE_KEY_PREFIX        = "CACHE_KEY_PREFIX"
+	CACHE_REDIS_ADDRESS     = "CACHE_REDIS_ADDRESS"
+	CACHE_REDIS_PASSWORD    = "CACHE_REDIS_PASSWORD"
+	CACHE_REDIS_DB          = "CACHE_REDIS_DB"
+	CACHE_SNAPSHOT_PATH     = "CACHE_SNAPSHOT_PATH"
+	CACHE_SNAPSHOT_INTERVAL = "CACHE_SNAPSHOT_INTERVAL"
 )
@@ -0,0 +1,176 @@
+package eventmanager
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultDeadLetterSize is the number of dead letters kept by a new event manager
+const DefaultDeadLetterSize = 1000
+
+// ErrDeadLetterNotFound is returned when re-driving or discarding a dead letter that does not exist
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// RetryPolicy decides how often a failed event is retried before it is dead-lettered
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the handler is called, including the first; at least 1
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for every following retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of a new event manager
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// delay returns the delay before the given retry, starting at 1
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for range retry - 1 {
+		if delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// DeadLetter is an event whose handler kept failing after all retries
+type DeadLetter struct {
+	ID           uint64    `json:"id"`
+	Subscription string    `json:"subscription,omitempty"`
+	Type         EventType `json:"type"`
+	Kind         string    `json:"kind"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Name         string    `json:"name"`
+	Sequence     uint64    `json:"sequence"`
+	Error        string    `json:"error"`
+	Attempts     int       `json:"attempts"`
+	LastAttempt  time.Time `json:"lastAttempt"`
+
+	event        ResourceEvent
+	subscription *Subscription
+}
+
+// deadLetterStore keeps the most recent dead letters, at most one per subscription and object,
+// so an object that keeps failing only holds its latest event
+type deadLetterStore struct {
+	mutex    sync.Mutex
+	capacity int
+	letters  []*DeadLetter
+	nextID   uint64
+}
+
+func newDeadLetterStore(capacity int) *deadLetterStore {
+	return &deadLetterStore{capacity: max(capacity, 0), letters: make([]*DeadLetter, 0)}
+}
+
+// add stores a dead letter for the event, replacing an older one for the same subscription and
+// object and dropping the oldest dead letter once the store is full
+func (d *deadLetterStore) add(sub *Subscription, event ResourceEvent, err error, attempts int) {
+	if d.capacity == 0 {
+		return
+	}
+	event.OldResource = nil
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := eventKey(event)
+	d.letters = slices.DeleteFunc(d.letters, func(letter *DeadLetter) bool {
+		return letter.subscription == sub && eventKey(letter.event) == key
+	})
+	if len(d.letters) >= d.capacity {
+		d.letters = slices.Delete(d.letters, 0, len(d.letters)-d.capacity+1)
+	}
+
+	d.nextID++
+	d.letters = append(d.letters, &DeadLetter{
+		ID:           d.nextID,
+		Subscription: sub.name,
+		Type:         event.Type,
+		Kind:         event.Resource.GetKind(),
+		Namespace:    event.Resource.GetNamespace(),
+		Name:         event.Resource.GetName(),
+		Sequence:     event.Sequence,
+		Error:        err.Error(),
+		Attempts:     attempts,
+		LastAttempt:  time.Now().UTC(),
+		event:        event,
+		subscription: sub,
+	})
+}
+
+// list returns copies of the dead letters, oldest first
+func (d *deadLetterStore) list() []DeadLetter {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	letters := make([]DeadLetter, 0, len(d.letters))
+	for _, letter := range d.letters {
+		letters = append(letters, *letter)
+	}
+	return letters
+}
+
+// take removes and returns the dead letters with the given IDs, all of them if none are given
+func (d *deadLetterStore) take(ids ...uint64) []*DeadLetter {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	taken := make([]*DeadLetter, 0)
+	d.letters = slices.DeleteFunc(d.letters, func(letter *DeadLetter) bool {
+		if len(ids) > 0 && !slices.Contains(ids, letter.ID) {
+			return false
+		}
+		taken = append(taken, letter)
+		return true
+	})
+	return taken
+}
+
+// DeadLetters returns the events whose handlers failed after all retries, oldest first
+func (em *EventManager) DeadLetters() []DeadLetter {
+	return em.deadLetters.list()
+}
+
+// Redrive removes the dead letter with the given ID and delivers its event to its subscription
+// again in the background. A failure is retried and dead-lettered again. The event is delivered
+// as it was when it failed, not with the current state of the object.
+func (em *EventManager) Redrive(id uint64) error {
+	letters := em.deadLetters.take(id)
+	if len(letters) == 0 {
+		return ErrDeadLetterNotFound
+	}
+	go em.redrive(letters)
+	return nil
+}
+
+// RedriveAll removes every dead letter and delivers the events again in the background, returning
+// the number of dead letters taken
+func (em *EventManager) RedriveAll() int {
+	letters := em.deadLetters.take()
+	go em.redrive(letters)
+	return len(letters)
+}
+
+// DiscardDeadLetter removes the dead letter with the given ID without delivering it
+func (em *EventManager) DiscardDeadLetter(id uint64) error {
+	if len(em.deadLetters.take(id)) == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// redrive delivers the events of the dead letters to their subscriptions, skipping
+// subscriptions that have been removed since
+func (em *EventManager) redrive(letters []*DeadLetter) {
+	for _, letter := range letters {
+		if letter.subscription.active() {
+			letter.subscription.deliver(letter.event)
+		}
+	}
+}
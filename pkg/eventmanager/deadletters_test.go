@@ -0,0 +1,140 @@
+package eventmanager
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestFailedEventIsRetriedThenDeadLettered(t *testing.T) {
+	em := NewEventManager(WithDefaultRetry(fastRetry))
+	calls := 0
+	sub := em.SubscribeE(Filter{}, func(ResourceEvent) error {
+		calls++
+		return errors.New("status write failed")
+	}, WithName("writer"))
+
+	resource := newTestResource("Machine", "m")
+	resource.SetNamespace("default")
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: resource})
+
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	letters := em.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(letters))
+	}
+	letter := letters[0]
+	if letter.Subscription != "writer" || letter.Kind != "Machine" || letter.Namespace != "default" || letter.Name != "m" ||
+		letter.Error != "status write failed" || letter.Attempts != 3 || letter.LastAttempt.IsZero() || letter.Sequence != 1 {
+		t.Fatalf("unexpected dead letter %+v", letter)
+	}
+	if stats := sub.Stats(); stats.Retried != 2 || stats.Failed != 1 {
+		t.Fatalf("expected 2 retries and 1 failure, got %+v", stats)
+	}
+}
+
+func TestRetriedEventThatSucceedsIsNotDeadLettered(t *testing.T) {
+	em := NewEventManager(WithDefaultRetry(fastRetry))
+	calls := 0
+	em.SubscribeE(Filter{}, func(ResourceEvent) error {
+		calls++
+		if calls == 1 {
+			return errors.New("conflict")
+		}
+		return nil
+	})
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "m")})
+
+	if calls != 2 || len(em.DeadLetters()) != 0 {
+		t.Fatalf("expected success on the second attempt without dead letters, got %d calls and %d dead letters", calls, len(em.DeadLetters()))
+	}
+}
+
+func TestPanickingHandlerIsDeadLettered(t *testing.T) {
+	em := NewEventManager(WithDefaultRetry(RetryPolicy{MaxAttempts: 1}))
+	em.SubscribeAll(func(ResourceEvent) { panic("boom") })
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "m")})
+
+	letters := em.DeadLetters()
+	if len(letters) != 1 || letters[0].Error != "handler panicked: boom" {
+		t.Fatalf("expected the panic as a dead letter, got %+v", letters)
+	}
+}
+
+func TestDeadLettersKeepLatestEventPerObjectWithinCapacity(t *testing.T) {
+	em := NewEventManager(WithDefaultRetry(RetryPolicy{MaxAttempts: 1}), WithDeadLetterSize(2))
+	em.SubscribeE(Filter{}, func(ResourceEvent) error { return errors.New("failed") })
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "c")})
+
+	letters := em.DeadLetters()
+	if len(letters) != 2 || letters[0].Name != "b" || letters[1].Name != "c" {
+		t.Fatalf("expected dead letters for b and c, got %+v", letters)
+	}
+
+	em.Publish(ResourceEvent{Type: EventDelete, Resource: newTestResource("Machine", "b")})
+	letters = em.DeadLetters()
+	if len(letters) != 2 || letters[0].Name != "c" || letters[1].Name != "b" || letters[1].Type != EventDelete {
+		t.Fatalf("expected the latest event for b to replace the older one, got %+v", letters)
+	}
+}
+
+func TestRedriveDeliversDeadLetterAgain(t *testing.T) {
+	em := NewEventManager(WithDefaultRetry(RetryPolicy{MaxAttempts: 1}))
+	failing := true
+	delivered := make(chan string, 10)
+	em.SubscribeE(Filter{}, func(event ResourceEvent) error {
+		if failing {
+			return errors.New("failed")
+		}
+		delivered <- event.Resource.GetName()
+		return nil
+	})
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "c")})
+	letters := em.DeadLetters()
+	failing = false
+
+	if err := em.Redrive(letters[0].ID); err != nil {
+		t.Fatalf("unexpected error re-driving: %v", err)
+	}
+	if got := <-delivered; got != "a" {
+		t.Fatalf("expected a to be re-driven, got %s", got)
+	}
+	if err := em.Redrive(letters[0].ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("expected a re-driven dead letter to be gone, got %v", err)
+	}
+
+	if err := em.DiscardDeadLetter(letters[1].ID); err != nil {
+		t.Fatalf("unexpected error discarding: %v", err)
+	}
+	if redriven := em.RedriveAll(); redriven != 1 {
+		t.Fatalf("expected 1 dead letter to be re-driven, got %d", redriven)
+	}
+	if got := <-delivered; got != "c" {
+		t.Fatalf("expected c to be re-driven, got %s", got)
+	}
+	if len(em.DeadLetters()) != 0 {
+		t.Fatalf("expected no dead letters left, got %+v", em.DeadLetters())
+	}
+}
+
+func TestRetryPolicyDelayDoublesUpToMax(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for retry, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 60: 5 * time.Second} {
+		if got := policy.delay(retry); got != want {
+			t.Errorf("retry %d: expected %s, got %s", retry, want, got)
+		}
+	}
+}
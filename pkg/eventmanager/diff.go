@@ -0,0 +1,128 @@
+package eventmanager
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FieldChange is a field that differs between the old and new resource of an UPDATE event
+type FieldChange struct {
+	// Path is the dot separated path of the field, e.g. status.phase or spec.version
+	Path string `json:"path"`
+	// Old is the previous value, nil if the field was added
+	Old any `json:"old,omitempty"`
+	// New is the current value, nil if the field was removed
+	New any `json:"new,omitempty"`
+}
+
+// ignoredDiffPaths change on every write and are left out of the diff
+var ignoredDiffPaths = []string{
+	"metadata.resourceVersion",
+	"metadata.managedFields",
+}
+
+// changeSet holds the changes of an UPDATE event. They are computed when they are first asked for,
+// once for all subscriptions the event is delivered to.
+type changeSet struct {
+	once      sync.Once
+	oldObject map[string]any
+	newObject map[string]any
+	changes   []FieldChange
+}
+
+func newChangeSet(oldObject, newObject map[string]any) *changeSet {
+	return &changeSet{oldObject: oldObject, newObject: newObject}
+}
+
+// get returns the changes, computing them on the first call
+func (c *changeSet) get() []FieldChange {
+	if c == nil {
+		return nil
+	}
+	c.once.Do(func() {
+		c.changes = diffObjects(c.oldObject, c.newObject)
+		c.oldObject, c.newObject = nil, nil
+	})
+	return c.changes
+}
+
+// paths returns a change set with the paths of the changes but not their values
+func (c *changeSet) paths() *changeSet {
+	changes := c.get()
+	if changes == nil {
+		return nil
+	}
+	paths := &changeSet{changes: make([]FieldChange, 0, len(changes))}
+	// The paths are complete, get must not compute anything
+	paths.once.Do(func() {})
+	for _, change := range changes {
+		paths.changes = append(paths.changes, FieldChange{Path: change.Path})
+	}
+	return paths
+}
+
+// Changes returns the fields that differ between OldResource and Resource, sorted by path.
+// They are computed on the first call. It is nil for events other than UPDATE and for updates
+// without the old resource. Events read from the history only have the paths of their changes.
+func (e ResourceEvent) Changes() []FieldChange {
+	return e.changes.get()
+}
+
+// Changed reports whether the field at path, a field below it or the object containing it changed.
+// It is false for events other than UPDATE.
+func (e ResourceEvent) Changed(path string) bool {
+	for _, change := range e.Changes() {
+		if change.Path == path || strings.HasPrefix(change.Path, path+".") || strings.HasPrefix(path, change.Path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Change returns the change of the field at exactly path, if it changed
+func (e ResourceEvent) Change(path string) (FieldChange, bool) {
+	for _, change := range e.Changes() {
+		if change.Path == path {
+			return change, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// diffObjects returns the changed fields between two unstructured objects, sorted by path.
+// Nested maps are compared field by field; any other value, including lists, is compared whole.
+func diffObjects(oldObject, newObject map[string]any) []FieldChange {
+	changes := make([]FieldChange, 0)
+	diffValues(nil, oldObject, newObject, &changes)
+	slices.SortFunc(changes, func(a, b FieldChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+func diffValues(path []string, oldValue, newValue any, changes *[]FieldChange) {
+	joined := strings.Join(path, ".")
+	if slices.Contains(ignoredDiffPaths, joined) {
+		return
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]any)
+	newMap, newIsMap := newValue.(map[string]any)
+	if oldIsMap && newIsMap {
+		for key, oldField := range oldMap {
+			diffValues(append(slices.Clip(path), key), oldField, newMap[key], changes)
+		}
+		for key, newField := range newMap {
+			if _, exists := oldMap[key]; !exists {
+				diffValues(append(slices.Clip(path), key), nil, newField, changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*changes = append(*changes, FieldChange{Path: joined, Old: oldValue, New: newValue})
+	}
+}
@@ -0,0 +1,100 @@
+package eventmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPublishComputesChangesForUpdates(t *testing.T) {
+	oldCluster := &unstructured.Unstructured{Object: map[string]any{
+		"kind": "KubernetesCluster",
+		"metadata": map[string]any{
+			"name":            "c",
+			"resourceVersion": "1",
+			"labels":          map[string]any{"team": "a"},
+		},
+		"spec": map[string]any{
+			"version":  "1.30",
+			"topology": map[string]any{"workers": []any{map[string]any{"replicas": int64(3)}}},
+		},
+		"status": map[string]any{"phase": "Provisioning"},
+	}}
+	newCluster := oldCluster.DeepCopy()
+	newCluster.SetResourceVersion("2")
+	newCluster.SetLabels(nil)
+	_ = unstructured.SetNestedField(newCluster.Object, "1.31", "spec", "version")
+	_ = unstructured.SetNestedSlice(newCluster.Object, []any{map[string]any{"replicas": int64(5)}}, "spec", "topology", "workers")
+	_ = unstructured.SetNestedField(newCluster.Object, "Running", "status", "phase")
+	_ = unstructured.SetNestedField(newCluster.Object, true, "status", "ready")
+
+	em := NewEventManager()
+	var received ResourceEvent
+	em.SubscribeAll(func(event ResourceEvent) { received = event })
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: newCluster, OldResource: oldCluster})
+
+	want := []FieldChange{
+		{Path: "metadata.labels", Old: map[string]any{"team": "a"}},
+		{Path: "spec.topology.workers", Old: []any{map[string]any{"replicas": int64(3)}}, New: []any{map[string]any{"replicas": int64(5)}}},
+		{Path: "spec.version", Old: "1.30", New: "1.31"},
+		{Path: "status.phase", Old: "Provisioning", New: "Running"},
+		{Path: "status.ready", New: true},
+	}
+	if !reflect.DeepEqual(received.Changes(), want) {
+		t.Fatalf("expected changes %v, got %v", want, received.Changes())
+	}
+
+	if !received.Changed("status") || !received.Changed("spec.topology") || !received.Changed("metadata.labels.team") {
+		t.Fatalf("expected parent, child and replaced paths to be reported as changed")
+	}
+	if received.Changed("metadata.name") || received.Changed("metadata.resourceVersion") {
+		t.Fatalf("expected unchanged and ignored paths not to be reported as changed")
+	}
+	if change, ok := received.Change("status.phase"); !ok || change.Old != "Provisioning" || change.New != "Running" {
+		t.Fatalf("expected status.phase transition, got %v", change)
+	}
+
+	history := em.EventsSince(0)
+	if len(history) != 1 || history[0].OldResource != nil || len(history[0].Changes()) != len(want) {
+		t.Fatalf("expected the history to keep the changes without the old resource, got %+v", history)
+	}
+	for i, change := range history[0].Changes() {
+		if change != (FieldChange{Path: want[i].Path}) {
+			t.Errorf("expected the history to keep only the path of %s, got %+v", want[i].Path, change)
+		}
+	}
+}
+
+func TestPublishLeavesChangesEmptyWithoutOldResource(t *testing.T) {
+	em := NewEventManager()
+	var received ResourceEvent
+	em.SubscribeAll(func(event ResourceEvent) { received = event })
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: newTestResource("Machine", "m")})
+
+	if received.Changes() != nil {
+		t.Fatalf("expected no changes without the old resource, got %v", received.Changes())
+	}
+}
+
+func TestPublishOnlyComputesChangesWhenAsked(t *testing.T) {
+	oldMachine := newTestResource("Machine", "m")
+	newMachine := oldMachine.DeepCopy()
+	newMachine.SetLabels(map[string]string{"team": "a"})
+
+	em := NewEventManager(WithHistorySize(0))
+	var filtered, received ResourceEvent
+	em.SubscribeFiltered(Filter{ChangedOnly: true}, func(event ResourceEvent) { filtered = event })
+	em.SubscribeAll(func(event ResourceEvent) { received = event })
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: newMachine, OldResource: oldMachine})
+
+	if filtered.Resource != nil {
+		t.Fatal("expected a label change to be filtered out by ChangedOnly")
+	}
+	if received.changes == nil || received.changes.oldObject == nil {
+		t.Fatal("expected the changes not to be computed before a handler asks for them")
+	}
+	if !received.Changed("metadata.labels") {
+		t.Fatalf("expected the label change once asked for, got %v", received.Changes())
+	}
+}
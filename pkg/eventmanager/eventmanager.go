@@ -1,9 +1,13 @@
 package eventmanager
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/vitistack/common/pkg/loggers/vlog"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -19,100 +23,405 @@ const (
 	EventDelete EventType = "DELETE"
 )
 
-// ResourceEvent represents a resource change event
+// ResourceEvent represents a resource change event.
+// Sequence and Timestamp are set by Publish, which also prepares the changes returned by Changes.
 type ResourceEvent struct {
 	Type     EventType
 	Resource *unstructured.Unstructured
+	// OldResource is the previous state of the resource on UPDATE events, if known
+	OldResource *unstructured.Unstructured
+	Sequence    uint64
+	Timestamp   time.Time
+	// changes are the fields that differ between OldResource and Resource
+	changes *changeSet
 }
 
 // EventHandler is a function that handles resource events
 type EventHandler func(event ResourceEvent)
 
-// EventManager manages event subscriptions and notifications
+// EventHandlerE is a function that handles resource events and reports failures.
+// A failed event is retried with backoff and dead-lettered once the retries are used up.
+type EventHandlerE func(event ResourceEvent) error
+
+// EventManager manages event subscriptions and notifications.
+// Each instance is independent; tests can create their own with NewEventManager.
 type EventManager struct {
-	handlers       map[string][]EventHandler
-	mutex          sync.RWMutex
-	globalHandlers []EventHandler
+	// subscriptions is replaced, never modified in place, so Publish can iterate
+	// over it without holding the lock while handlers run
+	subscriptions []*Subscription
+	mutex         sync.RWMutex
+	history       *eventHistory
+	deadLetters   *deadLetterStore
+	// defaultQueueSize, defaultQueuePolicy and defaultRetry apply to subscriptions without
+	// WithQueue and WithRetry
+	defaultQueueSize   int
+	defaultQueuePolicy QueuePolicy
+	defaultRetry       RetryPolicy
 }
 
-// NewEventManager creates a new event manager
-func NewEventManager() *EventManager {
-	return &EventManager{
-		handlers:       make(map[string][]EventHandler),
-		globalHandlers: make([]EventHandler, 0),
+// Subscription is a registered handler and the filter selecting the events it receives.
+// It stays registered until Unsubscribe is called.
+type Subscription struct {
+	manager *EventManager
+	name    string
+	filter  Filter
+	handler EventHandlerE
+	retry   RetryPolicy
+
+	queueSize   int
+	queuePolicy QueuePolicy
+	// queue is nil for subscriptions whose handler is invoked synchronously by Publish
+	queue *eventQueue
+
+	// done is closed by Unsubscribe and stops waiting retries
+	done     chan struct{}
+	doneOnce sync.Once
+
+	retried atomic.Int64
+	failed  atomic.Int64
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*Subscription)
+
+// WithName names the subscription in its statistics
+func WithName(name string) SubscribeOption {
+	return func(s *Subscription) {
+		s.name = name
 	}
 }
 
-// Subscribe registers a handler for events of a specific resource kind
-func (em *EventManager) Subscribe(resourceKind string, eventHandler EventHandler) {
-	em.mutex.Lock()
-	defer em.mutex.Unlock()
+// WithQueue gives the subscription its own queue of at most size events and a worker that
+// invokes the handler, so a slow handler does not hold up Publish. The policy decides what
+// happens when the queue is full. A size of 0 invokes the handler synchronously in Publish.
+func WithQueue(size int, policy QueuePolicy) SubscribeOption {
+	return func(s *Subscription) {
+		s.queueSize = size
+		s.queuePolicy = policy
+	}
+}
+
+// WithRetry sets how failed events of the subscription are retried. Retries of a subscription
+// without a queue delay Publish, so handlers that may fail should also be given a queue.
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(s *Subscription) {
+		s.retry = policy
+	}
+}
+
+// SubscriptionStats is a point-in-time view of a subscription and its queue
+type SubscriptionStats struct {
+	Name      string      `json:"name,omitempty"`
+	Kinds     []string    `json:"kinds,omitempty"`
+	Policy    QueuePolicy `json:"policy,omitempty"`
+	Capacity  int         `json:"capacity"`
+	Depth     int         `json:"depth"`
+	Delivered int64       `json:"delivered"`
+	Dropped   int64       `json:"dropped"`
+	Coalesced int64       `json:"coalesced"`
+	// Retried is the number of failed handler calls that were retried
+	Retried int64 `json:"retried"`
+	// Failed is the number of events that were dead-lettered
+	Failed int64 `json:"failed"`
+}
+
+// Option configures an EventManager
+type Option func(*EventManager)
+
+// WithHistorySize sets the number of published events kept in the history
+func WithHistorySize(size int) Option {
+	return func(em *EventManager) {
+		em.history = newEventHistory(size)
+	}
+}
+
+// WithDefaultQueue gives every subscription that is not subscribed WithQueue a queue of at most
+// size events with the given policy. By default handlers are invoked synchronously by Publish.
+func WithDefaultQueue(size int, policy QueuePolicy) Option {
+	return func(em *EventManager) {
+		em.defaultQueueSize = size
+		em.defaultQueuePolicy = policy
+	}
+}
+
+// WithDefaultRetry sets how failed events are retried for subscriptions without WithRetry
+func WithDefaultRetry(policy RetryPolicy) Option {
+	return func(em *EventManager) {
+		em.defaultRetry = policy
+	}
+}
 
-	if _, exists := em.handlers[resourceKind]; !exists {
-		em.handlers[resourceKind] = make([]EventHandler, 0)
+// WithDeadLetterSize sets the number of dead letters kept; 0 discards failed events
+func WithDeadLetterSize(size int) Option {
+	return func(em *EventManager) {
+		em.deadLetters = newDeadLetterStore(size)
 	}
-	em.handlers[resourceKind] = append(em.handlers[resourceKind], eventHandler)
+}
+
+// NewEventManager creates a new event manager, by default keeping the last DefaultHistorySize
+// events and DefaultDeadLetterSize dead letters and retrying with DefaultRetryPolicy
+func NewEventManager(options ...Option) *EventManager {
+	em := &EventManager{
+		subscriptions: make([]*Subscription, 0),
+		history:       newEventHistory(DefaultHistorySize),
+		deadLetters:   newDeadLetterStore(DefaultDeadLetterSize),
+		defaultRetry:  DefaultRetryPolicy,
+	}
+	for _, option := range options {
+		option(em)
+	}
+	return em
+}
+
+// SetHistorySize changes the number of published events kept in the history, keeping the most
+// recent ones. A size of 0 disables the history; sequence numbers are still assigned.
+func (em *EventManager) SetHistorySize(size int) {
+	em.history.resize(size)
+}
+
+// EventsSince returns the events in the history published after the given sequence number,
+// oldest first, limited to the given resource kinds if any are given. Events older than the
+// history are not returned; callers that need to detect a gap can compare the sequence number
+// of the first returned event with the one they asked for.
+func (em *EventManager) EventsSince(sequence uint64, kinds ...string) []ResourceEvent {
+	return em.history.since(sequence, kinds)
+}
+
+// LatestSequence returns the sequence number of the last published event, 0 if none
+func (em *EventManager) LatestSequence() uint64 {
+	return em.history.latest()
+}
+
+// Subscribe registers a handler for events of a specific resource kind
+func (em *EventManager) Subscribe(resourceKind string, eventHandler EventHandler, options ...SubscribeOption) *Subscription {
+	sub := em.SubscribeFiltered(Filter{Kinds: []string{resourceKind}}, eventHandler, options...)
 	vlog.Info("Subscribed handler for resource kind: " + resourceKind)
+	return sub
 }
 
 // SubscribeAll registers a handler for all resource events
-func (em *EventManager) SubscribeAll(eventHandler EventHandler) {
+func (em *EventManager) SubscribeAll(eventHandler EventHandler, options ...SubscribeOption) *Subscription {
+	sub := em.SubscribeFiltered(Filter{}, eventHandler, options...)
+	vlog.Info("Subscribed handler for all resource events")
+	return sub
+}
+
+// SubscribeFiltered registers a handler for the resource events selected by the filter.
+// The filter is applied once in Publish, so the handler only sees relevant events.
+func (em *EventManager) SubscribeFiltered(filter Filter, eventHandler EventHandler, options ...SubscribeOption) *Subscription {
+	return em.SubscribeE(filter, func(event ResourceEvent) error {
+		eventHandler(event)
+		return nil
+	}, options...)
+}
+
+// SubscribeE registers a handler that reports failures for the resource events selected by the
+// filter. Failed events are retried according to the subscription's retry policy and then
+// kept as dead letters, see DeadLetters.
+func (em *EventManager) SubscribeE(filter Filter, eventHandler EventHandlerE, options ...SubscribeOption) *Subscription {
+	sub := &Subscription{
+		manager:     em,
+		filter:      filter,
+		handler:     eventHandler,
+		retry:       em.defaultRetry,
+		queueSize:   em.defaultQueueSize,
+		queuePolicy: em.defaultQueuePolicy,
+		done:        make(chan struct{}),
+	}
+	for _, option := range options {
+		option(sub)
+	}
+	if sub.queueSize > 0 {
+		sub.queue = newEventQueue(sub.queueSize, sub.queuePolicy)
+		go sub.queue.run(sub.handle)
+	}
+
 	em.mutex.Lock()
 	defer em.mutex.Unlock()
 
-	em.globalHandlers = append(em.globalHandlers, eventHandler)
-	vlog.Info("Subscribed handler for all resource events")
+	em.subscriptions = append(slices.Clip(em.subscriptions), sub)
+	return sub
 }
 
-// Publish notifies all registered handlers of a resource event.
-//
-// Handlers are invoked synchronously. They must NOT be dispatched on a fresh
-// goroutine per event: informers replay every existing object as an ADD event
-// on startup, so a goroutine-per-event model spawns hundreds of concurrent
-// handlers at once (e.g. one per Machine), each allocating its own working set.
-// On large clusters that overruns the memory limit and the pod is OOMKilled.
-// Synchronous dispatch bounds concurrency to one handler per informer goroutine.
-func (em *EventManager) Publish(event ResourceEvent) {
+// SubscribeContext registers a handler like SubscribeFiltered that is unsubscribed when ctx is done
+func (em *EventManager) SubscribeContext(ctx context.Context, filter Filter, eventHandler EventHandler, options ...SubscribeOption) *Subscription {
+	sub := em.SubscribeFiltered(filter, eventHandler, options...)
+	context.AfterFunc(ctx, sub.Unsubscribe)
+	return sub
+}
+
+// Unsubscribe removes the subscription from its event manager and discards its queued events.
+// It is safe to call more than once and from within the handler. An event being published or
+// handled while it is called may still be delivered to the handler.
+func (s *Subscription) Unsubscribe() {
+	em := s.manager
+	em.mutex.Lock()
+	em.subscriptions = slices.DeleteFunc(slices.Clone(em.subscriptions), func(sub *Subscription) bool {
+		return sub == s
+	})
+	em.mutex.Unlock()
+
+	s.doneOnce.Do(func() { close(s.done) })
+	if s.queue != nil {
+		s.queue.close()
+	}
+}
+
+// active reports whether the subscription has not been unsubscribed
+func (s *Subscription) active() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// deliver hands the event to the subscription's queue, or to its handler if it has none
+func (s *Subscription) deliver(event ResourceEvent) {
+	if s.queue == nil {
+		s.handle(event)
+		return
+	}
+	s.queue.push(event)
+}
+
+// handle calls the handler with the event, retrying with backoff while it fails, and
+// dead-letters the event once the retries are used up
+func (s *Subscription) handle(event ResourceEvent) {
+	resourceKind := event.Resource.GetKind()
+	attempts := max(s.retry.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			s.retried.Add(1)
+			select {
+			case <-time.After(s.retry.delay(attempt - 1)):
+			case <-s.done:
+				return
+			}
+		}
+		start := time.Now()
+		err = safeInvoke(s.handler, event, resourceKind, "event handler")
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		handlerDuration.WithLabelValues(s.name, resourceKind, result).Observe(time.Since(start).Seconds())
+		if err == nil {
+			return
+		}
+	}
+
+	s.failed.Add(1)
+	vlog.Warn("Event handler failed, moving event to dead letters",
+		"subscription: ", s.name,
+		"kind: ", resourceKind,
+		"namespace: ", event.Resource.GetNamespace(),
+		"name: ", event.Resource.GetName(),
+		"attempts: ", attempts,
+		"error: ", err.Error())
+	s.manager.deadLetters.add(s, event, err, attempts)
+}
+
+// Stats returns a point-in-time view of the subscription and its queue
+func (s *Subscription) Stats() SubscriptionStats {
+	stats := SubscriptionStats{
+		Name:    s.name,
+		Kinds:   s.filter.Kinds,
+		Retried: s.retried.Load(),
+		Failed:  s.failed.Load(),
+	}
+	if s.queue == nil {
+		return stats
+	}
+
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+	stats.Policy = s.queue.policy
+	stats.Capacity = s.queue.capacity
+	stats.Depth = s.queue.events.Len()
+	stats.Delivered = s.queue.delivered
+	stats.Dropped = s.queue.dropped
+	stats.Coalesced = s.queue.coalesced
+	return stats
+}
+
+// SubscriptionStats returns the statistics of every registered subscription
+func (em *EventManager) SubscriptionStats() []SubscriptionStats {
+	em.mutex.RLock()
+	subscriptions := em.subscriptions
+	em.mutex.RUnlock()
+
+	stats := make([]SubscriptionStats, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		stats = append(stats, sub.Stats())
+	}
+	return stats
+}
+
+// SubscriptionCount returns the number of registered subscriptions
+func (em *EventManager) SubscriptionCount() int {
 	em.mutex.RLock()
 	defer em.mutex.RUnlock()
+	return len(em.subscriptions)
+}
 
+// Publish notifies all registered handlers of a resource event.
+//
+// Handlers must NOT be dispatched on a fresh goroutine per event: informers
+// replay every existing object as an ADD event on startup, so a
+// goroutine-per-event model spawns hundreds of concurrent handlers at once
+// (e.g. one per Machine), each allocating its own working set. On large
+// clusters that overruns the memory limit and the pod is OOMKilled.
+//
+// Handlers of subscriptions without a queue are invoked synchronously, which
+// bounds concurrency to one handler per informer goroutine. Subscriptions with
+// a queue have exactly one worker each, so concurrency is bounded by the number
+// of subscriptions and memory by their queue sizes, and a slow handler only
+// delays its own events.
+func (em *EventManager) Publish(event ResourceEvent) {
 	if event.Resource == nil {
 		vlog.Error("Cannot publish event with nil resource", nil)
 		return
 	}
 
-	resourceKind := event.Resource.GetKind()
-
-	// Notify specific handlers for this resource kind
-	if handlers, exists := em.handlers[resourceKind]; exists {
-		for _, handler := range handlers {
-			safeInvoke(handler, event, resourceKind, "event handler")
-		}
+	// The changes are only computed if a filter, a handler or the history asks for them
+	if event.Type == EventUpdate && event.OldResource != nil {
+		event.changes = newChangeSet(event.OldResource.Object, event.Resource.Object)
 	}
 
-	// Notify global handlers
-	for _, handler := range em.globalHandlers {
-		safeInvoke(handler, event, resourceKind, "global event handler")
+	event = em.history.record(event)
+	eventsPublished.WithLabelValues(event.Resource.GetKind(), string(event.Type)).Inc()
+
+	em.mutex.RLock()
+	subscriptions := em.subscriptions
+	em.mutex.RUnlock()
+
+	for _, sub := range subscriptions {
+		if sub.filter.matches(event) {
+			sub.deliver(event)
+		}
 	}
 }
 
 // safeInvoke runs a single handler synchronously, recovering from panics so one
 // faulty handler cannot crash the process or stop sibling handlers from running.
-func safeInvoke(h EventHandler, event ResourceEvent, resourceKind, label string) {
+// A panic is returned as an error so the event is retried like any other failure.
+func safeInvoke(h EventHandlerE, event ResourceEvent, resourceKind, label string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			handlerPanics.WithLabelValues(resourceKind).Inc()
 			vlog.Error("Panic in "+label,
 				fmt.Errorf("%v", r),
 				"kind", resourceKind,
 				"stack", string(debug.Stack()))
+			err = fmt.Errorf("handler panicked: %v", r)
 		}
 	}()
-	h(event)
+	return h(event)
 }
 
-// Global event manager instance
-var EventBus *EventManager
-
-func init() {
-	EventBus = NewEventManager()
-}
+// EventBus is the event manager the operator's watchers publish to
+var EventBus = NewEventManager()
@@ -0,0 +1,88 @@
+package eventmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnsubscribeStopsDelivery(t *testing.T) {
+	em := NewEventManager()
+	var first, second int
+	sub := em.Subscribe("Machine", func(ResourceEvent) { first++ })
+	em.SubscribeAll(func(ResourceEvent) { second++ })
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+
+	if first != 1 || second != 2 {
+		t.Fatalf("expected 1 event for the removed handler and 2 for the other, got %d and %d", first, second)
+	}
+	if em.SubscriptionCount() != 1 {
+		t.Fatalf("expected 1 subscription left, got %d", em.SubscriptionCount())
+	}
+}
+
+func TestHandlerCanUnsubscribeItself(t *testing.T) {
+	em := NewEventManager()
+	received := 0
+	var sub *Subscription
+	sub = em.SubscribeAll(func(ResourceEvent) {
+		received++
+		sub.Unsubscribe()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+		em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish deadlocked when a handler unsubscribed itself")
+	}
+	if received != 1 {
+		t.Fatalf("expected the handler to receive 1 event, got %d", received)
+	}
+}
+
+func TestSubscribeContextDetachesWhenCancelled(t *testing.T) {
+	em := NewEventManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	em.SubscribeContext(ctx, Filter{}, func(ResourceEvent) {})
+
+	if em.SubscriptionCount() != 1 {
+		t.Fatalf("expected 1 subscription, got %d", em.SubscriptionCount())
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for em.SubscriptionCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not removed after the context was cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventManagersAreIsolated(t *testing.T) {
+	a := NewEventManager(WithHistorySize(1))
+	b := NewEventManager()
+	received := 0
+	b.SubscribeAll(func(ResourceEvent) { received++ })
+
+	a.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	a.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+
+	if received != 0 || b.LatestSequence() != 0 {
+		t.Fatalf("expected no events on the other event manager, got %d", received)
+	}
+	if len(a.EventsSince(0)) != 1 {
+		t.Fatalf("expected a history of 1 event, got %d", len(a.EventsSince(0)))
+	}
+}
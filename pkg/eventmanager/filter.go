@@ -0,0 +1,57 @@
+package eventmanager
+
+import (
+	"reflect"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Filter selects the events delivered to a subscription. Every set field must match;
+// an empty filter matches all events.
+type Filter struct {
+	// Kinds of resources, any kind if empty
+	Kinds []string
+	// Types of events, any type if empty
+	Types []EventType
+	// Namespaces of resources, any namespace if empty
+	Namespaces []string
+	// Name of the resource, any name if empty
+	Name string
+	// LabelSelector the resource's labels must match, any labels if nil
+	LabelSelector labels.Selector
+	// ChangedOnly drops UPDATE events where neither metadata.generation nor the status changed
+	// compared with OldResource, such as informer resyncs and label or annotation changes
+	ChangedOnly bool
+}
+
+// matches reports whether the filter selects the event
+func (f Filter) matches(event ResourceEvent) bool {
+	resource := event.Resource
+	switch {
+	case len(f.Kinds) > 0 && !slices.Contains(f.Kinds, resource.GetKind()):
+		return false
+	case len(f.Types) > 0 && !slices.Contains(f.Types, event.Type):
+		return false
+	case len(f.Namespaces) > 0 && !slices.Contains(f.Namespaces, resource.GetNamespace()):
+		return false
+	case f.Name != "" && f.Name != resource.GetName():
+		return false
+	case f.LabelSelector != nil && !f.LabelSelector.Matches(labels.Set(resource.GetLabels())):
+		return false
+	case f.ChangedOnly && !generationOrStatusChanged(event):
+		return false
+	}
+	return true
+}
+
+// generationOrStatusChanged reports whether an UPDATE event changed metadata.generation or the
+// status. Other events, and updates without the old resource to compare with, count as changed.
+// Only the two fields are compared, so filtering does not compute the event's full changes.
+func generationOrStatusChanged(event ResourceEvent) bool {
+	if event.Type != EventUpdate || event.OldResource == nil {
+		return true
+	}
+	return event.OldResource.GetGeneration() != event.Resource.GetGeneration() ||
+		!reflect.DeepEqual(event.OldResource.Object["status"], event.Resource.Object["status"])
+}
@@ -0,0 +1,83 @@
+package eventmanager
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSubscribeFilteredSelectsEvents(t *testing.T) {
+	resource := func(kind, namespace, name string, objectLabels map[string]string) *unstructured.Unstructured {
+		obj := newTestResource(kind, name)
+		obj.SetNamespace(namespace)
+		obj.SetLabels(objectLabels)
+		return obj
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		event  ResourceEvent
+		want   bool
+	}{
+		{"empty filter", Filter{}, ResourceEvent{Type: EventAdd, Resource: resource("Machine", "a", "m", nil)}, true},
+		{"kind", Filter{Kinds: []string{"Machine"}}, ResourceEvent{Type: EventAdd, Resource: resource("MachineClass", "", "m", nil)}, false},
+		{"type", Filter{Types: []EventType{EventAdd, EventDelete}}, ResourceEvent{Type: EventUpdate, Resource: resource("Machine", "a", "m", nil)}, false},
+		{"namespace", Filter{Namespaces: []string{"a", "b"}}, ResourceEvent{Type: EventAdd, Resource: resource("Machine", "b", "m", nil)}, true},
+		{"other namespace", Filter{Namespaces: []string{"a"}}, ResourceEvent{Type: EventAdd, Resource: resource("Machine", "c", "m", nil)}, false},
+		{"name", Filter{Name: "config"}, ResourceEvent{Type: EventAdd, Resource: resource("ConfigMap", "a", "other", nil)}, false},
+		{"labels", Filter{LabelSelector: labels.SelectorFromSet(labels.Set{"role": "worker"})}, ResourceEvent{Type: EventAdd, Resource: resource("Machine", "a", "m", map[string]string{"role": "worker"})}, true},
+		{"other labels", Filter{LabelSelector: labels.SelectorFromSet(labels.Set{"role": "worker"})}, ResourceEvent{Type: EventAdd, Resource: resource("Machine", "a", "m", map[string]string{"role": "control-plane"})}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := NewEventManager()
+			received := false
+			em.SubscribeFiltered(tt.filter, func(ResourceEvent) { received = true })
+			em.Publish(tt.event)
+			if received != tt.want {
+				t.Fatalf("expected delivered=%v, got %v", tt.want, received)
+			}
+		})
+	}
+}
+
+func TestChangedOnlyDropsUpdatesWithoutGenerationOrStatusChange(t *testing.T) {
+	em := NewEventManager()
+	var received []EventType
+	em.SubscribeFiltered(Filter{ChangedOnly: true}, func(event ResourceEvent) {
+		received = append(received, event.Type)
+	})
+
+	machine := func(generation int64, phase string, objectLabels map[string]string) *unstructured.Unstructured {
+		obj := newTestResource("Machine", "m")
+		obj.SetGeneration(generation)
+		obj.SetLabels(objectLabels)
+		if phase != "" {
+			_ = unstructured.SetNestedField(obj.Object, phase, "status", "phase")
+		}
+		return obj
+	}
+
+	update := func(oldResource, resource *unstructured.Unstructured) ResourceEvent {
+		return ResourceEvent{Type: EventUpdate, Resource: resource, OldResource: oldResource}
+	}
+
+	labelled := map[string]string{"a": "b"}
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: machine(1, "", nil)})
+	em.Publish(update(machine(1, "", nil), machine(1, "", nil)))                       // resync
+	em.Publish(update(machine(1, "", nil), machine(1, "", labelled)))                  // labels only
+	em.Publish(update(machine(1, "", labelled), machine(2, "", labelled)))             // spec change
+	em.Publish(update(machine(2, "", labelled), machine(2, "Running", labelled)))      // status change
+	em.Publish(update(machine(2, "Running", labelled), machine(2, "Running", nil)))    // labels only
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: machine(2, "Running", nil)}) // old state unknown
+	em.Publish(ResourceEvent{Type: EventDelete, Resource: machine(2, "Running", nil)})
+
+	want := []EventType{EventAdd, EventUpdate, EventUpdate, EventUpdate, EventDelete}
+	if !slices.Equal(received, want) {
+		t.Fatalf("expected %v, got %v", want, received)
+	}
+}
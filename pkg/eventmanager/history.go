@@ -0,0 +1,115 @@
+package eventmanager
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of events kept in the history of a new event manager
+const DefaultHistorySize = 1000
+
+// eventHistory is a fixed-size ring buffer of the most recently published events.
+// It also hands out the sequence numbers, so events are stored in sequence order.
+type eventHistory struct {
+	mutex    sync.RWMutex
+	events   []ResourceEvent
+	next     int
+	full     bool
+	sequence uint64
+}
+
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{
+		events: make([]ResourceEvent, max(size, 0)),
+	}
+}
+
+// record assigns the next sequence number and the current time to the event and stores it,
+// overwriting the oldest event once the history is full. The stored event keeps the paths of the
+// changes but not their values or the old resource, so the history holds at most one version of
+// each object per event.
+func (h *eventHistory) record(event ResourceEvent) ResourceEvent {
+	h.mutex.RLock()
+	enabled := len(h.events) > 0
+	h.mutex.RUnlock()
+
+	// The changes are computed before taking the lock, Publish is not held up on other writers
+	var paths *changeSet
+	if enabled {
+		paths = event.changes.paths()
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sequence++
+	event.Sequence = h.sequence
+	event.Timestamp = time.Now().UTC()
+
+	if len(h.events) == 0 {
+		return event
+	}
+
+	stored := event
+	stored.OldResource = nil
+	stored.changes = paths
+	h.events[h.next] = stored
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+	return event
+}
+
+// since returns the stored events with a sequence number greater than sequence, oldest first,
+// limited to the given kinds if any are given
+func (h *eventHistory) since(sequence uint64, kinds []string) []ResourceEvent {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	ordered := h.events[:h.next]
+	if h.full {
+		ordered = append(slices.Clone(h.events[h.next:]), h.events[:h.next]...)
+	}
+
+	events := make([]ResourceEvent, 0)
+	for _, event := range ordered {
+		if event.Sequence <= sequence {
+			continue
+		}
+		if len(kinds) > 0 && !slices.Contains(kinds, event.Resource.GetKind()) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// resize changes the number of stored events, keeping the most recent ones
+func (h *eventHistory) resize(size int) {
+	current := h.since(0, nil)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.events = make([]ResourceEvent, max(size, 0))
+	h.next = 0
+	h.full = false
+	if len(h.events) == 0 {
+		return
+	}
+	if len(current) > len(h.events) {
+		current = current[len(current)-len(h.events):]
+	}
+	copy(h.events, current)
+	h.next = len(current) % len(h.events)
+	h.full = len(current) == len(h.events)
+}
+
+// latest returns the sequence number of the last recorded event
+func (h *eventHistory) latest() uint64 {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.sequence
+}
@@ -0,0 +1,97 @@
+package eventmanager
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestResource(kind, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(kind)
+	obj.SetName(name)
+	return obj
+}
+
+func sequences(events []ResourceEvent) []uint64 {
+	result := make([]uint64, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Sequence)
+	}
+	return result
+}
+
+func TestPublishAssignsSequenceAndTimestamp(t *testing.T) {
+	em := NewEventManager()
+	var received []ResourceEvent
+	em.SubscribeAll(func(event ResourceEvent) {
+		received = append(received, event)
+	})
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: newTestResource("Machine", "a")})
+
+	if got := sequences(received); !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("expected handlers to receive sequences [1 2], got %v", got)
+	}
+	if received[0].Timestamp.IsZero() || received[1].Timestamp.Before(received[0].Timestamp) {
+		t.Fatalf("expected increasing timestamps, got %v and %v", received[0].Timestamp, received[1].Timestamp)
+	}
+	if em.LatestSequence() != 2 {
+		t.Fatalf("expected latest sequence 2, got %d", em.LatestSequence())
+	}
+}
+
+func TestEventsSinceFiltersBySequenceAndKind(t *testing.T) {
+	em := NewEventManager()
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("MachineClass", "b")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "c")})
+	em.Publish(ResourceEvent{Type: EventDelete, Resource: newTestResource("KubernetesCluster", "d")})
+
+	if got := sequences(em.EventsSince(1)); !slices.Equal(got, []uint64{2, 3, 4}) {
+		t.Fatalf("expected sequences [2 3 4], got %v", got)
+	}
+	if got := sequences(em.EventsSince(0, "Machine")); !slices.Equal(got, []uint64{1, 3}) {
+		t.Fatalf("expected Machine sequences [1 3], got %v", got)
+	}
+	if got := sequences(em.EventsSince(0, "MachineClass", "KubernetesCluster")); !slices.Equal(got, []uint64{2, 4}) {
+		t.Fatalf("expected sequences [2 4], got %v", got)
+	}
+	if got := em.EventsSince(4); len(got) != 0 {
+		t.Fatalf("expected no events after the latest sequence, got %v", sequences(got))
+	}
+}
+
+func TestHistoryKeepsOnlyTheMostRecentEvents(t *testing.T) {
+	em := NewEventManager()
+	em.SetHistorySize(3)
+	for range 5 {
+		em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	}
+
+	if got := sequences(em.EventsSince(0)); !slices.Equal(got, []uint64{3, 4, 5}) {
+		t.Fatalf("expected the last three events, got %v", got)
+	}
+
+	em.SetHistorySize(2)
+	if got := sequences(em.EventsSince(0)); !slices.Equal(got, []uint64{4, 5}) {
+		t.Fatalf("expected shrinking to keep the last two events, got %v", got)
+	}
+
+	em.SetHistorySize(4)
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	if got := sequences(em.EventsSince(0)); !slices.Equal(got, []uint64{4, 5, 6}) {
+		t.Fatalf("expected growing to keep existing events, got %v", got)
+	}
+
+	em.SetHistorySize(0)
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	if got := em.EventsSince(0); len(got) != 0 {
+		t.Fatalf("expected no history when disabled, got %v", sequences(got))
+	}
+	if em.LatestSequence() != 7 {
+		t.Fatalf("expected sequences to be assigned when the history is disabled, got %d", em.LatestSequence())
+	}
+}
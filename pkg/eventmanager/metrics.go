@@ -0,0 +1,27 @@
+package eventmanager
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vitistack_operator",
+		Name:      "events_published_total",
+		Help:      "Resource events published on the event bus, by kind and type.",
+	}, []string{"kind", "type"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vitistack_operator",
+		Name:      "event_handler_duration_seconds",
+		Help:      "Duration of event handler calls, by subscription, kind and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 10),
+	}, []string{"subscription", "kind", "result"})
+
+	handlerPanics = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vitistack_operator",
+		Name:      "event_handler_panics_total",
+		Help:      "Panics recovered in event handlers, by kind.",
+	}, []string{"kind"})
+)
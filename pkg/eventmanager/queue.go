@@ -0,0 +1,161 @@
+package eventmanager
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// QueuePolicy decides what Publish does when a subscription's queue is full
+type QueuePolicy string
+
+const (
+	// QueueBlock makes Publish wait until the subscriber has taken an event off its queue
+	QueueBlock QueuePolicy = "block"
+	// QueueDropOldest discards the oldest queued event to make room for the new one
+	QueueDropOldest QueuePolicy = "drop-oldest"
+	// QueueCoalesce replaces a queued event for the same object with the new one, so the
+	// subscriber only sees the latest state of each object. A new object waits for room like QueueBlock.
+	QueueCoalesce QueuePolicy = "coalesce"
+)
+
+// ParseQueuePolicy returns the queue policy with the given name
+func ParseQueuePolicy(name string) (QueuePolicy, error) {
+	switch policy := QueuePolicy(name); policy {
+	case QueueBlock, QueueDropOldest, QueueCoalesce:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown queue policy %q, expected %s, %s or %s", name, QueueBlock, QueueDropOldest, QueueCoalesce)
+	}
+}
+
+// eventQueue is a bounded queue of events for one subscription, drained by a single worker
+type eventQueue struct {
+	capacity int
+	policy   QueuePolicy
+
+	mutex sync.Mutex
+	// changed is signalled when an event is added or taken, or the queue is closed
+	changed *sync.Cond
+	events  *list.List
+	// keys holds the queued element of each object, used by QueueCoalesce
+	keys   map[string]*list.Element
+	closed bool
+
+	delivered int64
+	dropped   int64
+	coalesced int64
+}
+
+func newEventQueue(capacity int, policy QueuePolicy) *eventQueue {
+	q := &eventQueue{
+		capacity: capacity,
+		policy:   policy,
+		events:   list.New(),
+		keys:     make(map[string]*list.Element),
+	}
+	q.changed = sync.NewCond(&q.mutex)
+	return q
+}
+
+// push adds an event to the queue, applying the queue policy when it is full
+func (q *eventQueue) push(event ResourceEvent) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key := eventKey(event)
+	if q.policy == QueueCoalesce {
+		if element, queued := q.keys[key]; queued {
+			element.Value = coalesceEvents(element.Value.(ResourceEvent), event)
+			q.coalesced++
+			return
+		}
+	}
+
+	for q.events.Len() >= q.capacity && !q.closed {
+		if q.policy == QueueDropOldest {
+			q.remove(q.events.Front())
+			q.dropped++
+			continue
+		}
+		q.changed.Wait()
+	}
+	if q.closed {
+		return
+	}
+
+	element := q.events.PushBack(event)
+	if q.policy == QueueCoalesce {
+		q.keys[key] = element
+	}
+	q.changed.Broadcast()
+}
+
+// pop takes the oldest event off the queue, waiting for one. It returns false once the queue is closed.
+func (q *eventQueue) pop() (ResourceEvent, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.events.Len() == 0 && !q.closed {
+		q.changed.Wait()
+	}
+	if q.closed {
+		return ResourceEvent{}, false
+	}
+
+	event := q.remove(q.events.Front())
+	q.delivered++
+	q.changed.Broadcast()
+	return event, true
+}
+
+func (q *eventQueue) remove(element *list.Element) ResourceEvent {
+	event := q.events.Remove(element).(ResourceEvent)
+	if q.keys[eventKey(event)] == element {
+		delete(q.keys, eventKey(event))
+	}
+	return event
+}
+
+// close discards the queued events and releases the worker and any blocked publishers
+func (q *eventQueue) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.events.Init()
+	clear(q.keys)
+	q.changed.Broadcast()
+}
+
+// run passes the queued events to handle until the queue is closed
+func (q *eventQueue) run(handle func(event ResourceEvent)) {
+	for {
+		event, ok := q.pop()
+		if !ok {
+			return
+		}
+		handle(event)
+	}
+}
+
+// eventKey identifies the object an event is for
+func eventKey(event ResourceEvent) string {
+	return event.Resource.GetKind() + "/" + event.Resource.GetNamespace() + "/" + event.Resource.GetName()
+}
+
+// coalesceEvents combines a queued event with a newer event for the same object into one
+// event carrying the latest state. An object added and then updated is still an ADD, and
+// consecutive updates keep the oldest known state so the changes cover both.
+func coalesceEvents(queued, event ResourceEvent) ResourceEvent {
+	switch {
+	case queued.Type == EventAdd && event.Type == EventUpdate:
+		event.Type = EventAdd
+		event.OldResource = nil
+		event.changes = nil
+	case queued.Type == EventUpdate && event.Type == EventUpdate && queued.OldResource != nil:
+		event.OldResource = queued.OldResource
+		event.changes = newChangeSet(queued.OldResource.Object, event.Resource.Object)
+	}
+	return event
+}
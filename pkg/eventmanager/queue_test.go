@@ -0,0 +1,186 @@
+package eventmanager
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// waitFor polls until the condition holds or fails the test after five seconds
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", description)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueuedSubscriberDoesNotBlockOthers(t *testing.T) {
+	em := NewEventManager(WithDefaultQueue(10, QueueBlock))
+	release := make(chan struct{})
+	slow := em.SubscribeAll(func(ResourceEvent) { <-release })
+	defer slow.Unsubscribe()
+
+	fast := make(chan string, 10)
+	em.SubscribeAll(func(event ResourceEvent) { fast <- event.Resource.GetName() })
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+
+	for _, want := range []string{"a", "b"} {
+		select {
+		case got := <-fast:
+			if got != want {
+				t.Fatalf("expected %s, got %s", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("fast subscriber was held up by the slow one")
+		}
+	}
+	close(release)
+}
+
+func TestQueueBlockWaitsForRoom(t *testing.T) {
+	em := NewEventManager()
+	release := make(chan struct{})
+	var received []string
+	done := make(chan struct{})
+	em.SubscribeAll(func(event ResourceEvent) {
+		<-release
+		received = append(received, event.Resource.GetName())
+		if len(received) == 3 {
+			close(done)
+		}
+	}, WithQueue(1, QueueBlock))
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for _, name := range []string{"a", "b", "c"} {
+			em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", name)})
+		}
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected publish to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-published
+	<-done
+	if !slices.Equal(received, []string{"a", "b", "c"}) {
+		t.Fatalf("expected every event in order, got %v", received)
+	}
+}
+
+func TestQueueDropOldestDiscardsOldestEvents(t *testing.T) {
+	em := NewEventManager()
+	release := make(chan struct{})
+	received := make(chan string, 10)
+	sub := em.SubscribeAll(func(event ResourceEvent) {
+		<-release
+		received <- event.Resource.GetName()
+	}, WithQueue(2, QueueDropOldest))
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	// Wait for the worker to take the first event, so the queue only holds the later ones
+	waitFor(t, "the first event to be taken", func() bool { return sub.Stats().Delivered == 1 })
+	for _, name := range []string{"b", "c", "d", "e"} {
+		em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", name)})
+	}
+	close(release)
+
+	var got []string
+	for range 3 {
+		got = append(got, <-received)
+	}
+	if !slices.Equal(got, []string{"a", "d", "e"}) {
+		t.Fatalf("expected a, d and e, got %v", got)
+	}
+	if stats := sub.Stats(); stats.Dropped != 2 || stats.Policy != QueueDropOldest || stats.Capacity != 2 {
+		t.Fatalf("expected 2 dropped events, got %+v", stats)
+	}
+}
+
+func TestQueueCoalesceKeepsLatestEventPerObject(t *testing.T) {
+	em := NewEventManager()
+	release := make(chan struct{})
+	received := make(chan ResourceEvent, 10)
+	sub := em.SubscribeAll(func(event ResourceEvent) {
+		<-release
+		received <- event
+	}, WithQueue(10, QueueCoalesce))
+
+	machine := func(name, phase string) *unstructured.Unstructured {
+		obj := newTestResource("Machine", name)
+		_ = unstructured.SetNestedField(obj.Object, phase, "status", "phase")
+		return obj
+	}
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: machine("blocker", "Pending")})
+	waitFor(t, "the first event to be taken", func() bool { return sub.Stats().Delivered == 1 })
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: machine("a", "Pending")})
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: machine("a", "Provisioning"), OldResource: machine("a", "Pending")})
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: machine("b", "Provisioning"), OldResource: machine("b", "Pending")})
+	em.Publish(ResourceEvent{Type: EventUpdate, Resource: machine("b", "Running"), OldResource: machine("b", "Provisioning")})
+	close(release)
+
+	<-received
+	a, b := <-received, <-received
+	if a.Resource.GetName() != "a" || a.Type != EventAdd || a.OldResource != nil {
+		t.Fatalf("expected an ADD of a with its latest state, got %s %s", a.Type, a.Resource.GetName())
+	}
+	if phase, _, _ := unstructured.NestedString(a.Resource.Object, "status", "phase"); phase != "Provisioning" {
+		t.Fatalf("expected the latest state of a, got phase %s", phase)
+	}
+	if change, ok := b.Change("status.phase"); !ok || change.Old != "Pending" || change.New != "Running" {
+		t.Fatalf("expected coalesced updates of b to cover Pending to Running, got %+v", b.Changes())
+	}
+	if stats := sub.Stats(); stats.Coalesced != 2 {
+		t.Fatalf("expected 2 coalesced events, got %+v", stats)
+	}
+}
+
+func TestUnsubscribeReleasesBlockedPublish(t *testing.T) {
+	em := NewEventManager()
+	block := make(chan struct{})
+	defer close(block)
+	sub := em.SubscribeAll(func(ResourceEvent) { <-block }, WithQueue(1, QueueBlock))
+
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "a")})
+	waitFor(t, "the first event to be taken", func() bool { return sub.Stats().Delivered == 1 })
+	em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "b")})
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		em.Publish(ResourceEvent{Type: EventAdd, Resource: newTestResource("Machine", "c")})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	sub.Unsubscribe()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish stayed blocked after the subscription was removed")
+	}
+}
+
+func TestParseQueuePolicy(t *testing.T) {
+	for _, name := range []string{"block", "drop-oldest", "coalesce"} {
+		if _, err := ParseQueuePolicy(name); err != nil {
+			t.Errorf("expected %s to be valid: %v", name, err)
+		}
+	}
+	if _, err := ParseQueuePolicy("newest"); err == nil {
+		t.Error("expected an unknown policy to be rejected")
+	}
+}